@@ -0,0 +1,78 @@
+package main
+
+import (
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+)
+
+type Address struct {
+	Id           int    `json:"id"`
+	StreetNumber string `json:"streetNumber"`
+	StreetName   string `json:"streetName"`
+	City         string `json:"city"`
+	CountryId    int    `json:"countryId"`
+	CountryName  string `json:"countryName"`
+	Status       string `json:"status,omitempty"` // Status from address_status (Active/Inactive). Only set when retrieved as a customer's address
+}
+
+// addressSortFields maps the sortable Address fields to their columns
+var addressSortFields = map[string]string{
+	"id":          "address.address_id",
+	"streetName":  "address.street_name",
+	"city":        "address.city",
+	"countryName": "country.country_name",
+	"status":      "address_status.address_status",
+}
+
+// addressFilterFields maps the Address fields that can be used in filter[field][operator] query params to their columns
+var addressFilterFields = map[string]filterField{
+	"id":           {column: "address.address_id", kind: filterInt},
+	"streetNumber": {column: "address.street_number", kind: filterText},
+	"streetName":   {column: "address.street_name", kind: filterText},
+	"city":         {column: "address.city", kind: filterText},
+	"countryId":    {column: "country.country_id", kind: filterInt},
+	"countryName":  {column: "country.country_name", kind: filterText},
+	"status":       {column: "address_status.address_status", kind: filterText},
+}
+
+// AddressesByCustomerId returns the addresses of the customer with the given id as []Address, tagged with their status
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
+// []Address is returned in all cases, so requires a check for error being nil
+func AddressesByCustomerId(db *pgx.Conn, c fiber.Ctx, id int) ([]Address, error) {
+	var addresses []Address
+	var where whereClause
+	where.add("customer_address.customer_id = %s", id)
+	if ferr := parseFilters(c, &where, addressFilterFields); ferr != nil {
+		return addresses, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, addressSortFields, "address.address_id")
+	if ferr != nil {
+		return addresses, ferr
+	}
+	rows, err := queryPage(db, c,
+		`SELECT address.address_id, address.street_number, address.street_name, address.city, country.country_id, country.country_name, address_status.address_status
+		FROM customer_address
+		JOIN address ON address.address_id = customer_address.address_id
+		JOIN country ON country.country_id = address.country_id
+		JOIN address_status ON address_status.status_id = customer_address.status_id
+		`+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return addresses, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Address
+		err := rows.Scan(&a.Id, &a.StreetNumber, &a.StreetName, &a.City, &a.CountryId, &a.CountryName, &a.Status)
+		if err != nil {
+			return addresses, err
+		}
+		addresses = append(addresses, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return addresses, err
+	}
+	return addresses, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomerAddresses(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/customers/1/addresses", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Len(t, a.Get("data").InterSlice(), 8)
+	assert.Equal(t, 132, a.Get("data[0].id").Int())
+	assert.Equal(t, "Inactive", a.Get("data[0].status").Str())
+}
+
+func TestAddressesByCustomerIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := AddressesByCustomerId(db, c, 1)
+
+	assert.Equal(t, []Address(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
@@ -9,15 +9,45 @@ import (
 )
 
 type Author struct {
-	Id         int    `json:"id"`
-	AuthorName string `json:"authorName"`
+	Id         int      `json:"id"`
+	AuthorName string   `json:"authorName"`
+	Rank       *float32 `json:"rank,omitempty"` // Full-text search relevance. Only set when searching with match=fulltext
+}
+
+// authorSuggestionSources are used for "did you mean" suggestions when an author search has no results
+var authorSuggestionSources = map[string]suggestionSource{
+	"name": {table: "author", column: "author_name"},
+}
+
+// authorSortFields maps the sortable Author fields to their columns
+var authorSortFields = map[string]string{
+	"id":         "author.author_id",
+	"authorName": "author.author_name",
+}
+
+// authorFilterFields maps the Author fields that can be used in filter[field][operator] query params to their columns
+var authorFilterFields = map[string]filterField{
+	"id":         {column: "author.author_id", kind: filterInt},
+	"authorName": {column: "author.author_name", kind: filterText},
 }
 
 // AllAuthors returns all authors from the database as []Author
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
 // []Author is returned in all cases, so requires a check for error being nil
 func AllAuthors(db *pgx.Conn, c fiber.Ctx) ([]Author, error) {
 	var authors []Author
-	rows, err := db.Query(context.Background(), "SELECT * FROM author LIMIT $1 OFFSET $2", c.Locals("limit"), c.Locals("offset"))
+	var where whereClause
+	if ferr := parseFilters(c, &where, authorFilterFields); ferr != nil {
+		return authors, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, authorSortFields, "author.author_id")
+	if ferr != nil {
+		return authors, ferr
+	}
+
+	sql := "SELECT * FROM author " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return authors, err
 	}
@@ -38,27 +68,49 @@ func AllAuthors(db *pgx.Conn, c fiber.Ctx) ([]Author, error) {
 	return authors, nil
 }
 
-// AuthorsBySearchTerm returns []Author from the database where searchTerm = searchValue
+// AuthorById returns a single Author from the database with the given id
+// pgx.ErrNoRows is returned if no author exists with that id
+func AuthorById(db *pgx.Conn, c fiber.Ctx, id int) (Author, error) {
+	var a Author
+	err := db.QueryRow(context.Background(), "SELECT * FROM author WHERE author.author_id=$1", id).Scan(&a.Id, &a.AuthorName)
+	return a, err
+}
+
+// AuthorsBySearchTerm returns []Author from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
 // To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
 // []Author is returned in all cases, so requires a check for error being nil
-func AuthorsBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerm, searchValue string) ([]Author, error) {
+func AuthorsBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Author, error) {
 	var authors []Author
-	var sql string
-	switch searchTerm {
-	case "name":
-		sql = "SELECT * FROM author WHERE author.author_name=$1 LIMIT $2 OFFSET $3"
-	default:
-		return authors, errors.New("invalid search term")
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "name":
+			where.addSearchTerm("author.author_name", st)
+		default:
+			return authors, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, authorFilterFields); ferr != nil {
+		return authors, ferr
 	}
 
-	rows, err := db.Query(context.Background(), sql, searchValue, c.Locals("limit"), c.Locals("offset"))
+	orderBy, ferr := searchOrderBy(c, &where, authorSortFields, "author.author_id")
+	if ferr != nil {
+		return authors, ferr
+	}
+
+	sql := "SELECT author.author_id, author.author_name, " + where.rank() + " FROM author " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return authors, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var a Author
-		err := rows.Scan(&a.Id, &a.AuthorName)
+		err := rows.Scan(&a.Id, &a.AuthorName, &a.Rank)
 		if err != nil {
 			return authors, err
 		}
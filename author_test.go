@@ -19,6 +19,8 @@ func TestAuthorSearch(t *testing.T) {
 		expectedAuthor string
 	}{
 		{search: "name=Agatha Christie", expectedAuthor: "Agatha Christie"},
+		{search: "name=agatha christie&match=insensitive", expectedAuthor: "Agatha Christie"},
+		{search: "name=Agatha Chr&match=prefix", expectedAuthor: "Agatha Christie"},
 	}
 
 	r := initRouter()
@@ -43,6 +45,59 @@ func TestAuthorSearch(t *testing.T) {
 	}
 }
 
+func TestAuthorBooks(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedSize    int
+		expectedFirstId int
+	}{
+		{route: "/v1/authors/79/books", expectedSize: 39, expectedFirstId: 28},
+		{route: "/v1/authors/79/books?sort=publicationDate", expectedSize: 39, expectedFirstId: 4996},
+		{route: "/v1/authors/79/books?sort=-publicationDate", expectedSize: 39, expectedFirstId: 7585},
+		{route: "/v1/authors/79/books?sort=publicationDate&limit=5", expectedSize: 5, expectedFirstId: 4996},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Len(t, a["data"], test.expectedSize)
+			assert.Equal(t, test.expectedFirstId, a.Get("data[0].id").Int())
+		})
+	}
+}
+
+func TestAuthorSearchSuggestions(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/authors/search?name=Agatha Cristie", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "Agatha Christie", a.Get("errors[0].meta.suggestions.name[0]").Str())
+}
+
 func TestAllAuthorsError(t *testing.T) {
 	db := connectToDb()
 	c := initRouter().AcquireCtx()
@@ -59,7 +114,7 @@ func TestAuthorSearchError(t *testing.T) {
 	c := initRouter().AcquireCtx()
 	db.Close(context.Background())
 
-	res, err := AuthorsBySearchTerm(db, c, "name", "Agatha Christie")
+	res, err := AuthorsBySearchTerm(db, c, []SearchTerm{{Term: "name", Value: "Agatha Christie"}})
 
 	assert.Equal(t, []Author(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
@@ -69,8 +124,18 @@ func TestAuthorSearchInvalidTerm(t *testing.T) {
 	var db *pgx.Conn
 	var c fiber.Ctx
 
-	res, err := AuthorsBySearchTerm(db, c, "foo", "bar")
+	res, err := AuthorsBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
 
 	assert.Equal(t, []Author(nil), res)
 	assert.Equal(t, errors.New("invalid search term"), err)
 }
+
+func TestAuthorByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := AuthorById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
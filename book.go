@@ -3,6 +3,9 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
@@ -17,20 +20,124 @@ type Book struct {
 	NumPages        int       `json:"numPages"`
 	PublicationDate time.Time `json:"publicationDate"`
 	PublisherId     int       `json:"publisherId"`
+	Rank            *float32  `json:"rank,omitempty"` // Full-text search relevance. Only set when searching with match=fulltext
+
+	// Related resources, only populated when requested with ?include=
+	Authors   []Author   `json:"authors,omitempty"`
+	Publisher *Publisher `json:"publisher,omitempty"`
+	Language  *Language  `json:"language,omitempty"`
+}
+
+var validBookIncludes = []string{"authors", "publisher", "language"}
+
+// validIncludes returns validBookIncludes, so that the related resources of a Book can be told apart from its other fields, see includer
+func (Book) validIncludes() []string {
+	return validBookIncludes
+}
+
+// validBookFields can be requested with ?fields= on the book endpoints, along with the fields of included resources with ?fields[include]=
+var validBookFields = map[string][]string{
+	"":          {"id", "title", "isbn", "languageId", "numPages", "publicationDate", "publisherId", "rank"},
+	"authors":   {"id", "authorName"},
+	"publisher": {"id", "publisherName"},
+	"language":  {"id", "languageCode", "languageName"},
 }
 
-type Language struct {
-	Id           int    `json:"id"`
-	LanguageCode string `json:"languageCode"`
-	LanguageName string `json:"languageName"`
+// bookColumns are the column of each Book field, in the order they are selected
+var bookColumns = []fieldColumn{
+	{"id", "book.book_id"},
+	{"title", "book.title"},
+	{"isbn", "book.isbn13"},
+	{"languageId", "book.language_id"},
+	{"numPages", "book.num_pages"},
+	{"publicationDate", "book.publication_date"},
+	{"publisherId", "book.publisher_id"},
+}
+
+// embeddedPublisherColumns and embeddedLanguageColumns are the columns of the included publisher and language of a Book, in the order they are selected
+var embeddedPublisherColumns = []fieldColumn{
+	{"id", "publisher.publisher_id"},
+	{"publisherName", "publisher.publisher_name"},
+}
+
+var embeddedLanguageColumns = []fieldColumn{
+	{"id", "book_language.language_id"},
+	{"languageCode", "book_language.language_code"},
+	{"languageName", "book_language.language_name"},
+}
+
+// validBookFacets can be requested with ?facets= on the book list and search endpoints
+var validBookFacets = []string{"language", "publisher", "decade"}
+
+// bookFacetQueries are how each of validBookFacets is counted, see queryFacets
+var bookFacetQueries = map[string]facetQuery{
+	"language": {
+		join:  "JOIN book_language ON book_language.language_id = book.language_id",
+		value: "book_language.language_id",
+		label: "book_language.language_name",
+	},
+	"publisher": {
+		join:  "JOIN publisher ON publisher.publisher_id = book.publisher_id",
+		value: "publisher.publisher_id",
+		label: "publisher.publisher_name",
+	},
+	"decade": {
+		value: "date_part('year', book.publication_date)::int / 10 * 10",
+		label: "(date_part('year', book.publication_date)::int / 10 * 10) || 's'",
+	},
+}
+
+// bookRangeTerms filter books by publication date and page count, on both the book list and search endpoints
+// publishedFrom and publishedTo accept a year (YYYY) or a date (YYYY-MM-DD) and are inclusive
+var bookRangeTerms = []string{"publishedFrom", "publishedTo", "minPages", "maxPages"}
+
+// bookSortFields maps the sortable Book fields to their columns
+var bookSortFields = map[string]string{
+	"id":              "book.book_id",
+	"title":           "book.title",
+	"isbn":            "book.isbn13",
+	"languageId":      "book.language_id",
+	"numPages":        "book.num_pages",
+	"publicationDate": "book.publication_date",
+	"publisherId":     "book.publisher_id",
+}
+
+// bookSuggestionSources are used for "did you mean" suggestions when a book search has no results
+var bookSuggestionSources = map[string]suggestionSource{
+	"title":  {table: "book", column: "title"},
+	"author": {table: "author", column: "author_name"},
+}
+
+// bookFilterFields maps the Book fields that can be used in filter[field][operator] query params to their columns
+var bookFilterFields = map[string]filterField{
+	"id":              {column: "book.book_id", kind: filterInt},
+	"title":           {column: "book.title", kind: filterText},
+	"isbn":            {column: "book.isbn13", kind: filterText},
+	"languageId":      {column: "book.language_id", kind: filterInt},
+	"numPages":        {column: "book.num_pages", kind: filterInt},
+	"publicationDate": {column: "book.publication_date", kind: filterDate},
+	"publisherId":     {column: "book.publisher_id", kind: filterInt},
 }
 
 // AllBooks returns all books from the database as []Book
+// Filtered by any bookRangeTerms and filter[field][operator] query params, and sorted by the 'sort' query param if given
+// Related resources are embedded if requested with ?include=
 // []Book is returned in all cases, so requires a check for error being nil
 func AllBooks(db *pgx.Conn, c fiber.Ctx) ([]Book, error) {
 	var books []Book
-	rows, err := db.Query(context.Background(),
-		`SELECT * FROM book LIMIT $1 OFFSET $2`, c.Locals("limit"), c.Locals("offset"))
+	where, err := bookSearchWhere(c, bookRangeSearchTerms(c))
+	if err != nil {
+		return books, err
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, bookSortFields, "book.book_id")
+	if ferr != nil {
+		return books, ferr
+	}
+
+	fields := bookSelectFields(c)
+	sql := "SELECT " + bookSelectColumns(fields) + " FROM book " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return books, err
 	}
@@ -38,7 +145,7 @@ func AllBooks(db *pgx.Conn, c fiber.Ctx) ([]Book, error) {
 
 	for rows.Next() {
 		var b Book
-		err := rows.Scan(&b.Id, &b.Title, &b.Isbn, &b.LanguageId, &b.NumPages, &b.PublicationDate, &b.PublisherId)
+		err := rows.Scan(b.scanTargets(fields)...)
 		if err != nil {
 			return books, err
 		}
@@ -48,35 +155,304 @@ func AllBooks(db *pgx.Conn, c fiber.Ctx) ([]Book, error) {
 	if err = rows.Err(); err != nil {
 		return books, err
 	}
-	return books, nil
+	return books, embedBookIncludes(db, c, books)
+}
+
+// BookById returns a single Book from the database with the given id
+// Related resources are embedded if requested with ?include=
+// pgx.ErrNoRows is returned if no book exists with that id
+func BookById(db *pgx.Conn, c fiber.Ctx, id int) (Book, error) {
+	var b Book
+	fields := bookSelectFields(c)
+	err := db.QueryRow(context.Background(), "SELECT "+bookSelectColumns(fields)+" FROM book WHERE book.book_id=$1", id).
+		Scan(b.scanTargets(fields)...)
+	if err != nil {
+		return b, err
+	}
+
+	books := []Book{b}
+	err = embedBookIncludes(db, c, books)
+	return books[0], err
 }
 
-// BooksBySearchTerm returns []Book from the database where searchTerm = searchValue
+// BooksBySearchTerm returns []Book from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
 // To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
 // []Book is returned in all cases, so requires a check for error being nil
-func BooksBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerm, searchValue string) ([]Book, error) {
+func BooksBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Book, error) {
+	var books []Book
+	where, err := bookSearchWhere(c, searchTerms)
+	if err != nil {
+		return books, err
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, bookSortFields, "book.book_id")
+	if ferr != nil {
+		return books, ferr
+	}
+
+	fields := bookSelectFields(c)
+	sql := "SELECT " + bookSelectColumns(fields) + ", " + where.rank() + `
+		FROM book ` + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return books, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b Book
+		err := rows.Scan(append(b.scanTargets(fields), &b.Rank)...)
+		if err != nil {
+			return books, err
+		}
+		books = append(books, b)
+	}
+	return books, nil
+}
+
+// BookFacets returns the number of books matching searchTerms and any filter query params for each value of the given facets
+// The same search terms as the book list or search must be given, so that the counts are for the whole result set rather than one page of it
+func BookFacets(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm, facets []string) (map[string]Facet, error) {
+	where, err := bookSearchWhere(c, searchTerms)
+	if err != nil {
+		return nil, err
+	}
+	return queryFacets(db, facets, bookFacetQueries, "book.book_id", "book", where)
+}
+
+// bookSelectFields returns the Book fields to select, which are all of them unless fewer were requested with ?fields=
+// The id, any sort fields and the ids of included resources are always selected too, as they are needed for cursors and includes
+func bookSelectFields(c fiber.Ctx) []string {
+	requested := requestedFields(c, "")
+	if len(requested) == 0 {
+		var fields []string
+		for _, bc := range bookColumns {
+			fields = append(fields, bc.field)
+		}
+		return fields
+	}
+
+	needed := append([]string{"id"}, requested...)
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		needed = append(needed, strings.TrimPrefix(strings.TrimSpace(field), "-"))
+	}
+	if hasInclude(c, "publisher") {
+		needed = append(needed, "publisherId")
+	}
+	if hasInclude(c, "language") {
+		needed = append(needed, "languageId")
+	}
+
+	var fields []string
+	for _, bc := range bookColumns {
+		if slices.Contains(needed, bc.field) {
+			fields = append(fields, bc.field)
+		}
+	}
+	return fields
+}
+
+// bookSelectColumns returns the columns of the given Book fields, for a SELECT
+func bookSelectColumns(fields []string) string {
+	return selectColumns(bookColumns, fields)
+}
+
+// scanTargets returns pointers to the given fields of b, in the order of bookColumns, for scanning a row selected with bookSelectColumns
+func (b *Book) scanTargets(fields []string) []any {
+	return scanTargets(bookColumns, fields, map[string]any{
+		"id":              &b.Id,
+		"title":           &b.Title,
+		"isbn":            &b.Isbn,
+		"languageId":      &b.LanguageId,
+		"numPages":        &b.NumPages,
+		"publicationDate": &b.PublicationDate,
+		"publisherId":     &b.PublisherId,
+	})
+}
+
+// bookSearchWhere returns the WHERE clause for books matching searchTerms and any filter[field][operator] query params
+// It is shared by the book list and search queries and their facets, so that facets are always counted from the same books as the results.
+// An 'invalid search term' error is returned for an undefined search term, or a *fiber.Error with a 400 status for a malformed value
+func bookSearchWhere(c fiber.Ctx, searchTerms []SearchTerm) (whereClause, error) {
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "title":
+			where.addSearchTerm("book.title", st)
+		case "isbn":
+			if st.Match == matchFulltext {
+				return where, unsupportedMatchError(st)
+			}
+			where.addSearchTerm("book.isbn13", st)
+		case "author":
+			where.addRelatedSearchTerm("book_author JOIN author ON author.author_id = book_author.author_id",
+				"book_author.book_id = book.book_id", "author.author_name", st)
+		case "publishedFrom", "publishedTo", "minPages", "maxPages":
+			if err := addBookRangeTerm(&where, st); err != nil {
+				return where, err
+			}
+		default:
+			return where, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, bookFilterFields); ferr != nil {
+		return where, ferr
+	}
+	return where, nil
+}
+
+// bookRangeSearchTerms returns any bookRangeTerms in the query params as []SearchTerm, for the book list endpoint
+func bookRangeSearchTerms(c fiber.Ctx) []SearchTerm {
+	var searchTerms []SearchTerm
+	for _, term := range bookRangeTerms {
+		if c.Query(term) != "" {
+			searchTerms = append(searchTerms, SearchTerm{Term: term, Value: c.Query(term)})
+		}
+	}
+	return searchTerms
+}
+
+// addBookRangeTerm adds the condition for one of bookRangeTerms to where. The search term's match mode does not apply to ranges
+// A *fiber.Error with a 400 status is returned if the value is malformed
+func addBookRangeTerm(where *whereClause, st SearchTerm) *fiber.Error {
+	switch st.Term {
+	case "publishedFrom", "publishedTo":
+		from, to, err := parseDateRange(st.Value)
+		if err != nil {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid %v '%v', must be a year (YYYY) or date (YYYY-MM-DD)", st.Term, st.Value))
+		}
+		if st.Term == "publishedFrom" {
+			where.add("book.publication_date >= %s", from)
+		} else {
+			where.add("book.publication_date < %s", to)
+		}
+	case "minPages", "maxPages":
+		pages, err := parseInt32(st.Value)
+		if err != nil || pages < 0 {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid %v '%v', must be a positive integer", st.Term, st.Value))
+		}
+		if st.Term == "minPages" {
+			where.add("book.num_pages >= %s", pages)
+		} else {
+			where.add("book.num_pages <= %s", pages)
+		}
+	default:
+		return fiber.NewError(fiber.ErrBadRequest.Code, "invalid range term")
+	}
+	return nil
+}
 
+// parseDateRange parses a year (YYYY) or date (YYYY-MM-DD), returning the first day it covers and the day after the last
+func parseDateRange(value string) (time.Time, time.Time, error) {
+	if year, err := time.Parse("2006", value); err == nil {
+		return year, year.AddDate(1, 0, 0), nil
+	}
+
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return day, day.AddDate(0, 0, 1), nil
+}
+
+// BooksByLanguageId returns the books written in the language with the given id as []Book
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given, see bookSortFields
+// []Book is returned in all cases, so requires a check for error being nil
+func BooksByLanguageId(db *pgx.Conn, c fiber.Ctx, id int) ([]Book, error) {
+	var books []Book
+	var where whereClause
+	where.add("book.language_id = %s", id)
+	if ferr := parseFilters(c, &where, bookFilterFields); ferr != nil {
+		return books, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, bookSortFields, "book.book_id")
+	if ferr != nil {
+		return books, ferr
+	}
+	rows, err := queryPage(db, c, "SELECT * FROM book "+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return books, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b Book
+		err := rows.Scan(&b.Id, &b.Title, &b.Isbn, &b.LanguageId, &b.NumPages, &b.PublicationDate, &b.PublisherId)
+		if err != nil {
+			return books, err
+		}
+		books = append(books, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return books, err
+	}
+	return books, nil
+}
+
+// BooksByAuthorId returns the books written by the author with the given id as []Book
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given, see bookSortFields
+// []Book is returned in all cases, so requires a check for error being nil
+func BooksByAuthorId(db *pgx.Conn, c fiber.Ctx, id int) ([]Book, error) {
 	var books []Book
-	var sql string
-	switch searchTerm {
-	case "title":
-		sql = "SELECT * from book WHERE book.title=$1 LIMIT $2 OFFSET $3"
-	case "isbn":
-		sql = "SELECT * from book WHERE book.isbn13=$1 LIMIT $2 OFFSET $3"
-	case "author":
-		sql = `SELECT book.book_id, book.title, book.isbn13, book.language_id, book.num_pages, book.publication_date, book.publisher_id 
+	var where whereClause
+	where.add("book_author.author_id = %s", id)
+	if ferr := parseFilters(c, &where, bookFilterFields); ferr != nil {
+		return books, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, bookSortFields, "book.book_id")
+	if ferr != nil {
+		return books, ferr
+	}
+	rows, err := queryPage(db, c,
+		`SELECT book.book_id, book.title, book.isbn13, book.language_id, book.num_pages, book.publication_date, book.publisher_id
 		FROM book
 		JOIN book_author ON book_author.book_id = book.book_id
-		JOIN author ON author.author_id = book_author.author_id
-		AND author.author_name=$1 LIMIT $2 OFFSET $3`
-	default:
-		return books, errors.New("invalid search term")
+		`+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return books, err
 	}
+	defer rows.Close()
 
-	rows, err := db.Query(context.Background(), sql, searchValue, c.Locals("limit"), c.Locals("offset"))
+	for rows.Next() {
+		var b Book
+		err := rows.Scan(&b.Id, &b.Title, &b.Isbn, &b.LanguageId, &b.NumPages, &b.PublicationDate, &b.PublisherId)
+		if err != nil {
+			return books, err
+		}
+		books = append(books, b)
+	}
+
+	if err = rows.Err(); err != nil {
+		return books, err
+	}
+	return books, nil
+}
+
+// BooksByPublisherId returns the books published by the publisher with the given id as []Book
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given, see bookSortFields
+// []Book is returned in all cases, so requires a check for error being nil
+func BooksByPublisherId(db *pgx.Conn, c fiber.Ctx, id int) ([]Book, error) {
+	var books []Book
+	var where whereClause
+	where.add("book.publisher_id = %s", id)
+	if ferr := parseFilters(c, &where, bookFilterFields); ferr != nil {
+		return books, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, bookSortFields, "book.book_id")
+	if ferr != nil {
+		return books, ferr
+	}
+	rows, err := queryPage(db, c, "SELECT * FROM book "+where.sql()+" "+orderBy, where.args...)
 	if err != nil {
 		return books, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var b Book
@@ -86,5 +462,111 @@ func BooksBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerm, searchValue string
 		}
 		books = append(books, b)
 	}
+
+	if err = rows.Err(); err != nil {
+		return books, err
+	}
 	return books, nil
 }
+
+// embedBookIncludes attaches the related resources requested with ?include= to each of the given books
+// Each include is fetched with a single query for all books, rather than one query per book
+func embedBookIncludes(db *pgx.Conn, c fiber.Ctx, books []Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	var bookIds, publisherIds, languageIds []int
+	for _, b := range books {
+		bookIds = append(bookIds, b.Id)
+		publisherIds = append(publisherIds, b.PublisherId)
+		languageIds = append(languageIds, b.LanguageId)
+	}
+
+	if hasInclude(c, "authors") {
+		authorsByBook := make(map[int][]Author)
+		rows, err := db.Query(context.Background(),
+			`SELECT book_author.book_id, author.author_id, author.author_name
+			FROM book_author
+			JOIN author ON author.author_id = book_author.author_id
+			WHERE book_author.book_id = ANY($1)
+			ORDER BY author.author_id`, bookIds)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var bookId int
+			var a Author
+			if err := rows.Scan(&bookId, &a.Id, &a.AuthorName); err != nil {
+				rows.Close()
+				return err
+			}
+			authorsByBook[bookId] = append(authorsByBook[bookId], a)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i := range books {
+			books[i].Authors = authorsByBook[books[i].Id]
+		}
+	}
+
+	if hasInclude(c, "publisher") {
+		publishers := make(map[int]Publisher)
+		fields := includeFields(c, "publisher", embeddedPublisherColumns)
+		rows, err := db.Query(context.Background(),
+			"SELECT "+selectColumns(embeddedPublisherColumns, fields)+" FROM publisher WHERE publisher.publisher_id = ANY($1)", publisherIds)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var p Publisher
+			targets := scanTargets(embeddedPublisherColumns, fields, map[string]any{"id": &p.Id, "publisherName": &p.PublisherName})
+			if err := rows.Scan(targets...); err != nil {
+				rows.Close()
+				return err
+			}
+			publishers[p.Id] = p
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i := range books {
+			if p, ok := publishers[books[i].PublisherId]; ok {
+				books[i].Publisher = &p
+			}
+		}
+	}
+
+	if hasInclude(c, "language") {
+		languages := make(map[int]Language)
+		fields := includeFields(c, "language", embeddedLanguageColumns)
+		rows, err := db.Query(context.Background(),
+			"SELECT "+selectColumns(embeddedLanguageColumns, fields)+" FROM book_language WHERE book_language.language_id = ANY($1)", languageIds)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var l Language
+			targets := scanTargets(embeddedLanguageColumns, fields, map[string]any{"id": &l.Id, "languageCode": &l.LanguageCode, "languageName": &l.LanguageName})
+			if err := rows.Scan(targets...); err != nil {
+				rows.Close()
+				return err
+			}
+			languages[l.Id] = l
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for i := range books {
+			if l, ok := languages[books[i].LanguageId]; ok {
+				books[i].Language = &l
+			}
+		}
+	}
+
+	return nil
+}
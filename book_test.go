@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
@@ -20,7 +22,12 @@ func TestBookSearch(t *testing.T) {
 	}{
 		{search: "title=The Tempest", expectedTitle: "The Tempest"},
 		{search: "isbn=9781559277587", expectedTitle: "They Do It With Mirrors"},
-		{search: "author=Agatha Christie", expectedTitle: "Hercule Poirot's Christmas: A BBC Radio 4 Full-Cast Dramatisation"},
+		{search: "author=Agatha Christie", expectedTitle: "After the Funeral"},
+		{search: "author=Agatha Christie&isbn=9781559277587", expectedTitle: "They Do It With Mirrors"},
+		{search: "title=The Tempest&isbn=9780393978193", expectedTitle: "The Tempest"},
+		{search: "title=the tempest&match=insensitive", expectedTitle: "The Tempest"},
+		{search: "title=They Do It With Mir&match=prefix", expectedTitle: "They Do It With Mirrors"},
+		{search: "isbn=9781559277587&title=with mirrors&match=contains", expectedTitle: "They Do It With Mirrors"},
 	}
 
 	r := initRouter()
@@ -46,6 +53,114 @@ func TestBookSearch(t *testing.T) {
 
 }
 
+func TestBookFulltextSearch(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/books/search?title=christmas poirot&match=fulltext", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Len(t, a["data"], 2)
+	assert.Equal(t, 68, a.Get("data[0].id").Int())
+	assert.Greater(t, a.Get("data[0].rank").Float64(), a.Get("data[1].rank").Float64())
+}
+
+// TestBookAuthorSearchCoAuthors checks that a book is only returned once when more than one of its authors matches
+// Book 472 is by both Barbara and Camille Kingsolver
+func TestBookAuthorSearchCoAuthors(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/books/search?author=kingsolver&match=contains", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Len(t, a["data"], 4)
+	assert.Equal(t, 4, a.Get("meta.pagination.total").Int())
+	assert.Equal(t, 472, a.Get("data[1].id").Int())
+	assert.Equal(t, 586, a.Get("data[2].id").Int())
+}
+
+func TestBookRanges(t *testing.T) {
+	var tests = []struct {
+		route    string
+		from     time.Time
+		to       time.Time
+		minPages int
+		maxPages int
+	}{
+		{route: "/v1/books?publishedFrom=1990&publishedTo=2000", from: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), to: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), maxPages: 1 << 30},
+		{route: "/v1/books?minPages=100&maxPages=199", to: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), minPages: 100, maxPages: 199},
+		{route: "/v1/books?publishedFrom=1999-09-01&publishedTo=1999-09-01", from: time.Date(1999, 9, 1, 0, 0, 0, 0, time.UTC), to: time.Date(1999, 9, 2, 0, 0, 0, 0, time.UTC), maxPages: 1 << 30},
+		{route: "/v1/books/search?author=Agatha Christie&maxPages=199", to: time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), maxPages: 199},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var books struct{ Data []Book }
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			json.Unmarshal(body, &books)
+
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			assert.NotEmpty(t, books.Data)
+			for _, b := range books.Data {
+				assert.False(t, b.PublicationDate.Before(test.from))
+				assert.True(t, b.PublicationDate.Before(test.to))
+				assert.GreaterOrEqual(t, b.NumPages, test.minPages)
+				assert.LessOrEqual(t, b.NumPages, test.maxPages)
+			}
+		})
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	var tests = []struct {
+		value        string
+		expectedFrom time.Time
+		expectedTo   time.Time
+		isError      bool
+	}{
+		{value: "1990", expectedFrom: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), expectedTo: time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "1999-12-31", expectedFrom: time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC), expectedTo: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "1999-13-01", isError: true},
+		{value: "last year", isError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			from, to, err := parseDateRange(test.value)
+
+			assert.Equal(t, test.isError, err != nil)
+			assert.Equal(t, test.expectedFrom, from)
+			assert.Equal(t, test.expectedTo, to)
+		})
+	}
+}
+
 func TestAllBooksError(t *testing.T) {
 	db := connectToDb()
 	c := initRouter().AcquireCtx()
@@ -62,7 +177,7 @@ func TestBookSearchError(t *testing.T) {
 	c := initRouter().AcquireCtx()
 	db.Close(context.Background())
 
-	res, err := BooksBySearchTerm(db, c, "title", "The Tempest")
+	res, err := BooksBySearchTerm(db, c, []SearchTerm{{Term: "title", Value: "The Tempest"}})
 
 	assert.Equal(t, []Book(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
@@ -72,8 +187,111 @@ func TestBookSearchInvalidTerm(t *testing.T) {
 	var db *pgx.Conn
 	var c fiber.Ctx
 
-	res, err := BooksBySearchTerm(db, c, "foo", "bar")
+	res, err := BooksBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
 
 	assert.Equal(t, []Book(nil), res)
 	assert.Equal(t, errors.New("invalid search term"), err)
 }
+
+func TestBookById(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/books/1", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 1, a.Get("data.id").Int())
+	assert.Equal(t, "The World's First Love: Mary  Mother of God", a.Get("data.title").Str())
+	assert.Equal(t, 1010, a.Get("data.publisherId").Int())
+}
+
+func TestBookIncludes(t *testing.T) {
+	var tests = []struct {
+		route      string
+		bookPath   string
+		isIncluded bool
+	}{
+		{route: "/v1/books/1", bookPath: "data", isIncluded: false},
+		{route: "/v1/books/1?include=authors,publisher,language", bookPath: "data", isIncluded: true},
+		{route: "/v1/books?limit=1&include=authors,publisher,language", bookPath: "data[0]", isIncluded: true},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			if test.isIncluded {
+				assert.Equal(t, "Fulton J. Sheen", a.Get(test.bookPath+".authors[0].authorName").Str())
+				assert.Equal(t, "Ignatius Press", a.Get(test.bookPath+".publisher.publisherName").Str())
+				assert.Equal(t, "United States English", a.Get(test.bookPath+".language.languageName").Str())
+			} else {
+				assert.Nil(t, a.Get(test.bookPath+".authors").Data())
+				assert.Nil(t, a.Get(test.bookPath+".publisher").Data())
+				assert.Nil(t, a.Get(test.bookPath+".language").Data())
+			}
+		})
+	}
+}
+
+func TestBookByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := BookById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestBooksByLanguageIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := BooksByLanguageId(db, c, 1)
+
+	assert.Equal(t, []Book(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestBooksByAuthorIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := BooksByAuthorId(db, c, 79)
+
+	assert.Equal(t, []Book(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestBooksByPublisherIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := BooksByPublisherId(db, c, 1010)
+
+	assert.Equal(t, []Book(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
@@ -12,11 +13,35 @@ type Country struct {
 	CountryName string `json:"countryName"`
 }
 
+// countrySortFields maps the sortable Country fields to their columns
+var countrySortFields = map[string]string{
+	"id":          "country.country_id",
+	"countryName": "country.country_name",
+}
+
+// countryFilterFields maps the Country fields that can be used in filter[field][operator] query params to their columns
+var countryFilterFields = map[string]filterField{
+	"id":          {column: "country.country_id", kind: filterInt},
+	"countryName": {column: "country.country_name", kind: filterText},
+}
+
 // AllCountries returns all countries from the database as []Country
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
 // []Countries is returned in all cases, so requires a check for error being nil
 func AllCountries(db *pgx.Conn, c fiber.Ctx) ([]Country, error) {
 	var countries []Country
-	rows, err := db.Query(context.Background(), "SELECT * FROM country LIMIT $1 OFFSET $2", c.Locals("limit"), c.Locals("offset"))
+	var where whereClause
+	if ferr := parseFilters(c, &where, countryFilterFields); ferr != nil {
+		return countries, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, countrySortFields, "country.country_id")
+	if ferr != nil {
+		return countries, ferr
+	}
+
+	sql := "SELECT * FROM country " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return countries, err
 	}
@@ -36,3 +61,57 @@ func AllCountries(db *pgx.Conn, c fiber.Ctx) ([]Country, error) {
 	}
 	return countries, nil
 }
+
+// CountryById returns a single Country from the database with the given id
+// pgx.ErrNoRows is returned if no country exists with that id
+func CountryById(db *pgx.Conn, c fiber.Ctx, id int) (Country, error) {
+	var co Country
+	err := db.QueryRow(context.Background(), "SELECT * FROM country WHERE country.country_id=$1", id).Scan(&co.Id, &co.CountryName)
+	return co, err
+}
+
+// CountriesBySearchTerm returns []Country from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
+// To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
+// []Country is returned in all cases, so requires a check for error being nil
+func CountriesBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Country, error) {
+	var countries []Country
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "name":
+			if st.Match == matchFulltext {
+				return countries, unsupportedMatchError(st)
+			}
+			where.addSearchTerm("country.country_name", st)
+		default:
+			return countries, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, countryFilterFields); ferr != nil {
+		return countries, ferr
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, countrySortFields, "country.country_id")
+	if ferr != nil {
+		return countries, ferr
+	}
+
+	sql := "SELECT * FROM country " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return countries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var co Country
+		err := rows.Scan(&co.Id, &co.CountryName)
+		if err != nil {
+			return countries, err
+		}
+		countries = append(countries, co)
+	}
+	return countries, nil
+}
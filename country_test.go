@@ -2,11 +2,48 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 	"testing"
 
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestCountryCustomersOrders(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedFirstId int
+	}{
+		{route: "/v1/countries/149/customers", expectedFirstId: 1},
+		{route: "/v1/countries/149/orders", expectedFirstId: 1},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedFirstId, a.Get("data[0].id").Int())
+		})
+	}
+}
+
 func TestAllCountriesError(t *testing.T) {
 	db := connectToDb()
 	c := initRouter().AcquireCtx()
@@ -17,3 +54,34 @@ func TestAllCountriesError(t *testing.T) {
 	assert.Equal(t, []Country(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
 }
+
+func TestCountryByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := CountryById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestCountrySearchError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := CountriesBySearchTerm(db, c, []SearchTerm{{Term: "name", Value: "France"}})
+
+	assert.Equal(t, []Country(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestCountrySearchInvalidTerm(t *testing.T) {
+	var db *pgx.Conn
+	var c fiber.Ctx
+
+	res, err := CountriesBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
+
+	assert.Equal(t, []Country(nil), res)
+	assert.Equal(t, errors.New("invalid search term"), err)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const mimeTextCSV = "text/csv"
+
+// validFormats can be requested with ?format=, which takes precedence over the Accept header
+var validFormats = []string{"json", "csv"}
+
+// parseFormat is a middleware that chooses the format of the response, from the 'format' query param or else the Accept header.
+// CSV is stored in c.Locals("format") for SendGravityResponse, and JSON is the default. Errors are always sent as JSON
+func parseFormat(c fiber.Ctx) error {
+	format := c.Query("format")
+	switch {
+	case format == "":
+		if c.Accepts(fiber.MIMEApplicationJSON, mimeTextCSV) == mimeTextCSV {
+			format = "csv"
+		}
+	case !slices.Contains(validFormats, format):
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(fiber.StatusBadRequest),
+			Code:   "FORMAT-01",
+			Title:  "Invalid response format",
+			Detail: fmt.Sprintf("invalid format '%v'. valid formats: %v", format, validFormats),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	c.Locals("format", format)
+	return c.Next()
+}
+
+// wantsCSV reports whether parseFormat chose CSV for the response
+func wantsCSV(c fiber.Ctx) bool {
+	format, _ := c.Locals("format").(string)
+	return format == "csv"
+}
+
+// sendCSV sends data as CSV, with a header row of field names and a row for each resource.
+// Columns are the JSON fields of the resource type in struct order, so the header is the same for every page, even an empty one.
+// Related resources the resource type can embed are only given a column when requested with ?include=, and fields are limited to any requested with ?fields=.
+// Strings are written as they are, other values such as included resources or an order's destAddress as JSON, and fields omitted from the JSON response are left empty.
+// Returns a 406 GravityError if data is not a resource or a list of them, such as the grouped results of the unified search
+func sendCSV(c fiber.Ctx, status int, data interface{}) error {
+	resources, columns, ok := csvResources(c, data)
+	if !ok {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(fiber.StatusNotAcceptable),
+			Code:   "FORMAT-02",
+			Title:  "Invalid response format",
+			Detail: "csv format is only supported for lists of resources",
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			value := resource.Field(column.index)
+			if column.omitEmpty && isEmptyValue(value) {
+				continue
+			}
+			var cell interface{} = value.Interface()
+			if column.include {
+				cell = sparseValue(value, requestedFields(c, column.name), nil)
+			}
+			var err error
+			if record[i], err = csvCell(cell); err != nil {
+				return err
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, mimeTextCSV+"; charset=utf-8")
+	return c.Status(status).Send(buf.Bytes())
+}
+
+// csvColumn is a field of the resource type that is written as a column
+type csvColumn struct {
+	name      string
+	index     int
+	omitEmpty bool
+	include   bool // A related resource embedded with ?include=, such as a book's authors
+}
+
+// csvResources returns the resource structs in data along with the columns of their type, or false if data is not a resource or a list of them
+func csvResources(c fiber.Ctx, data interface{}) ([]reflect.Value, []csvColumn, bool) {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil, false
+	}
+
+	t := v.Type()
+	var resources []reflect.Value
+	if v.Kind() == reflect.Slice {
+		t = t.Elem()
+		for i := 0; i < v.Len(); i++ {
+			resources = append(resources, reflect.Indirect(v.Index(i)))
+		}
+	} else {
+		resources = append(resources, v)
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+	for _, resource := range resources {
+		if !resource.IsValid() {
+			return nil, nil, false
+		}
+	}
+
+	fields := requestedFields(c, "")
+	includes := validIncludesOf(t)
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		name, omitEmpty := jsonFieldName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		include := slices.Contains(includes, name)
+		if (include && !hasInclude(c, name)) || (!include && len(fields) > 0 && !slices.Contains(fields, name)) {
+			continue
+		}
+		columns = append(columns, csvColumn{name: name, index: i, omitEmpty: omitEmpty, include: include})
+	}
+	return resources, columns, true
+}
+
+// csvCell formats a field the way it is in the JSON response, without the quotes around strings, and null as an empty cell
+func csvCell(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var s string
+	switch {
+	case string(b) == "null":
+		return "", nil
+	case json.Unmarshal(b, &s) == nil:
+		return s, nil
+	default:
+		return string(b), nil
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVResponse(t *testing.T) {
+	var tests = []struct {
+		name                string
+		path                string
+		accept              string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "format param",
+			path:                "/customers?format=csv",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,firstName,lastName,email\n1,Ada,\"Lovelace, Countess\",ada@example.com\n2,Alan,Turing,alan@example.com\n",
+		},
+		{
+			name:                "accept header",
+			path:                "/customers",
+			accept:              "text/csv",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,firstName,lastName,email\n1,Ada,\"Lovelace, Countess\",ada@example.com\n2,Alan,Turing,alan@example.com\n",
+		},
+		{
+			name:                "format param over accept header",
+			path:                "/customers?format=json",
+			accept:              "text/csv",
+			expectedStatus:      200,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "omitted fields",
+			path:                "/books?format=csv",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,title,isbn,languageId,numPages,publicationDate,publisherId,rank\n1,Emma,123,1,474,1815-12-23T00:00:00Z,3,\n2,Persuasion,456,1,249,1817-12-20T00:00:00Z,3,0.5\n",
+		},
+		{
+			name:                "included resources",
+			path:                "/books?format=csv&include=publisher",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,title,isbn,languageId,numPages,publicationDate,publisherId,rank,publisher\n1,Emma,123,1,474,1815-12-23T00:00:00Z,3,,\"{\"\"id\"\":3,\"\"publisherName\"\":\"\"Penguin\"\"}\"\n2,Persuasion,456,1,249,1817-12-20T00:00:00Z,3,0.5,\n",
+		},
+		{
+			name:                "requested fields",
+			path:                "/books?format=csv&fields=title,id&include=publisher&fields[publisher]=publisherName",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,title,publisher\n1,Emma,\"{\"\"publisherName\"\":\"\"Penguin\"\"}\"\n2,Persuasion,\n",
+		},
+		{
+			name:                "nested values",
+			path:                "/orders?format=csv",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,orderDate,customerId,shippingMethodId,destAddress,subtotal,currentStatus\n1,2021-07-14T10:15:00Z,4,2,\"{\"\"id\"\":7,\"\"streetNumber\"\":\"\"221B\"\",\"\"streetName\"\":\"\"Baker Street\"\",\"\"city\"\":\"\"London\"\",\"\"countryId\"\":42,\"\"countryName\"\":\"\"United Kingdom\"\"}\",19.99,Delivered\n",
+		},
+		{
+			name:                "nested values and included resources",
+			path:                "/orders?format=csv&include=lines",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,orderDate,customerId,shippingMethodId,destAddress,subtotal,currentStatus,lines\n1,2021-07-14T10:15:00Z,4,2,\"{\"\"id\"\":7,\"\"streetNumber\"\":\"\"221B\"\",\"\"streetName\"\":\"\"Baker Street\"\",\"\"city\"\":\"\"London\"\",\"\"countryId\"\":42,\"\"countryName\"\":\"\"United Kingdom\"\"}\",19.99,Delivered,\"[{\"\"id\"\":1,\"\"bookId\"\":1,\"\"title\"\":\"\"Emma\"\",\"\"price\"\":19.99}]\"\n",
+		},
+		{
+			name:                "empty list",
+			path:                "/empty?format=csv",
+			expectedStatus:      200,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,firstName,lastName,email\n",
+		},
+		{name: "errors stay json", path: "/error?format=csv", expectedStatus: 404, expectedContentType: "application/json"},
+		{name: "not a list", path: "/grouped?format=csv", expectedStatus: 406, expectedContentType: "application/json"},
+		{name: "invalid format", path: "/customers?format=xml", expectedStatus: 400, expectedContentType: "application/json"},
+	}
+
+	rank := float32(0.5)
+	app := fiber.New()
+	app.Use(parseFormat)
+	app.Get("/customers", func(c fiber.Ctx) error {
+		customers := []Customer{
+			{Id: 1, FirstName: "Ada", LastName: "Lovelace, Countess", Email: "ada@example.com"},
+			{Id: 2, FirstName: "Alan", LastName: "Turing", Email: "alan@example.com"},
+		}
+		return SendGravityResponse(c, &GravityResponse{Data: customers})
+	})
+	app.Get("/books", func(c fiber.Ctx) error {
+		if err := parseIncludes(c, validBookIncludes); err != nil {
+			return err
+		}
+		if err := parseFields(c, validBookFields); err != nil {
+			return err
+		}
+		books := []Book{
+			{Id: 1, Title: "Emma", Isbn: "123", LanguageId: 1, NumPages: 474, PublicationDate: time.Date(1815, 12, 23, 0, 0, 0, 0, time.UTC), PublisherId: 3, Publisher: &Publisher{Id: 3, PublisherName: "Penguin"}},
+			{Id: 2, Title: "Persuasion", Isbn: "456", LanguageId: 1, NumPages: 249, PublicationDate: time.Date(1817, 12, 20, 0, 0, 0, 0, time.UTC), PublisherId: 3, Rank: &rank},
+		}
+		return SendGravityResponse(c, &GravityResponse{Data: books})
+	})
+	app.Get("/orders", func(c fiber.Ctx) error {
+		if err := parseIncludes(c, validOrderIncludes); err != nil {
+			return err
+		}
+		status := "Delivered"
+		price := pgtype.Numeric{Int: big.NewInt(1999), Exp: -2, Valid: true}
+		orders := []Order{{
+			Id:               1,
+			OrderDate:        time.Date(2021, 7, 14, 10, 15, 0, 0, time.UTC),
+			CustomerId:       4,
+			ShippingMethodId: 2,
+			DestAddress:      Address{Id: 7, StreetNumber: "221B", StreetName: "Baker Street", City: "London", CountryId: 42, CountryName: "United Kingdom"},
+			Subtotal:         price,
+			CurrentStatus:    &status,
+		}}
+		if hasInclude(c, "lines") {
+			orders[0].Lines = []OrderLine{{Id: 1, BookId: 1, Title: "Emma", Price: price}}
+		}
+		return SendGravityResponse(c, &GravityResponse{Data: orders})
+	})
+	app.Get("/empty", func(c fiber.Ctx) error {
+		return SendGravityResponse(c, &GravityResponse{Data: []Customer{}})
+	})
+	app.Get("/error", func(c fiber.Ctx) error {
+		return SendGravityResponse(c, &GravityResponse{Errors: []GravityError{{Status: "404", Code: "TEST-01", Title: "Not found"}}})
+	})
+	app.Get("/grouped", func(c fiber.Ctx) error {
+		return SendGravityResponse(c, &GravityResponse{Data: map[string]interface{}{"book": []Book{}}})
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.path, nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			assert.Equal(t, test.expectedContentType, resp.Header.Get("Content-Type"))
+			if test.expectedBody != "" {
+				assert.Equal(t, test.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func TestCSVRoute(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/customers?format=csv&limit=2", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, resp.Header.Get("Link"), `rel="next"`)
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "id,firstName,lastName,email", lines[0])
+
+	req, _ = http.NewRequest("GET", "/v1/customers/0?format=csv", nil)
+	resp, err = r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	res, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 404, resp.StatusCode)
+	assert.Equal(t, "404", res.Get("errors[0].status").Str())
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// cursor is the position after the last result of a page, given as the 'cursor' query param to fetch the page after it.
+// It is encoded as base64 JSON so that clients treat it as opaque. Fields are the sort fields it was made with,
+// so that a cursor can't be used with a different sort, and Values are the last result's value for each of them
+type cursor struct {
+	Fields []string `json:"f"`
+	Values []string `json:"v"`
+}
+
+// cursorOrderBy sorts by the 'sort' query param like parseSort, with primaryKey as a tie breaker, and applies any 'cursor' query param to where
+// It is used by the list and search functions, see applyCursor
+func cursorOrderBy(c fiber.Ctx, where *whereClause, sortableFields map[string]string, primaryKey string) (string, *fiber.Error) {
+	keys, ferr := parseSortKeys(c, sortableFields)
+	if ferr != nil {
+		return "", ferr
+	}
+	return applyCursor(c, where, keys, primaryKey)
+}
+
+// applyCursor adds a keyset condition to where for the rows after the 'cursor' query param, if given, in the order of keys.
+// Unlike an offset, this uses the sort key itself, so doesn't slow down on later pages or shift when rows are inserted.
+// The id of the model is always appended to keys as a tie breaker, so primaryKey must be the column of the 'id' field.
+// The sort fields are stored in c.Locals("cursorFields") so that SendGravityResponse can return the cursor for the next page.
+// Returns the ORDER BY clause for keys, or a *fiber.Error with a 400 status for an invalid cursor
+func applyCursor(c fiber.Ctx, where *whereClause, keys []sortKey, primaryKey string) (string, *fiber.Error) {
+	keys = append(keys, sortKey{field: "id", column: primaryKey})
+
+	var fields []string
+	var columns []string
+	for _, key := range keys {
+		fields = append(fields, key.field)
+		columns = append(columns, key.sql())
+	}
+	orderBy := "ORDER BY " + strings.Join(columns, ", ")
+
+	if !paginated(c) {
+		return orderBy, nil
+	}
+	c.Locals("cursorFields", fields)
+
+	if c.Query("cursor") == "" {
+		return orderBy, nil
+	}
+	if c.Query("offset") != "" {
+		return "", fiber.NewError(fiber.ErrBadRequest.Code, "cursor cannot be combined with offset")
+	}
+
+	cur, err := decodeCursor(c.Query("cursor"))
+	if err != nil || !slices.Equal(cur.Fields, fields) || len(cur.Values) != len(keys) {
+		return "", fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid cursor '%v' for sort fields %v", c.Query("cursor"), fields))
+	}
+
+	// For keys (a, b, id) this is: a > $1 OR (a = $1 AND b > $2) OR (a = $1 AND b = $2 AND id > $3), with < for descending keys
+	// Values are passed as strings, which Postgres parses as the type of the column they are compared to
+	placeholders := make([]string, len(keys))
+	for i, value := range cur.Values {
+		placeholders[i] = where.arg(value)
+	}
+	var after []string
+	for i, key := range keys {
+		var conditions []string
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, keys[j].column+" = "+placeholders[j])
+		}
+		comparison := " > "
+		if key.desc {
+			comparison = " < "
+		}
+		conditions = append(conditions, key.column+comparison+placeholders[i])
+		after = append(after, "("+strings.Join(conditions, " AND ")+")")
+	}
+	where.conditions = append(where.conditions, "("+strings.Join(after, " OR ")+")")
+	c.Locals("cursor", true)
+
+	return orderBy, nil
+}
+
+// nextCursor returns the cursor for the page after results, taking the values of the sort fields from the last result's JSON.
+// An empty string is returned if the request doesn't support cursors, or queryPage found no more results after this page
+func nextCursor(c fiber.Ctx, results interface{}) string {
+	fields, ok := c.Locals("cursorFields").([]string)
+	if !ok {
+		return ""
+	}
+	if more, _ := c.Locals("morePages").(bool); !more {
+		return ""
+	}
+
+	v := reflect.ValueOf(results)
+	if v.Kind() != reflect.Slice || v.Len() == 0 {
+		return ""
+	}
+
+	last, err := json.Marshal(v.Index(v.Len() - 1).Interface())
+	if err != nil {
+		return ""
+	}
+	var lastFields map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(last)))
+	decoder.UseNumber() // Keeps numeric values such as subtotals exact
+	if err := decoder.Decode(&lastFields); err != nil {
+		return ""
+	}
+
+	cur := cursor{Fields: fields}
+	for _, field := range fields {
+		switch value := lastFields[field].(type) {
+		case json.Number:
+			cur.Values = append(cur.Values, value.String())
+		case string:
+			cur.Values = append(cur.Values, value)
+		default:
+			return ""
+		}
+	}
+	return encodeCursor(cur)
+}
+
+func encodeCursor(cur cursor) string {
+	b, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var cur cursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(b, &cur)
+	return cur, err
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyCursor(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    url.Values
+		expected string
+	}{
+		{name: "no cursor", query: url.Values{"sort": {"title"}}, expected: "ORDER BY book.title ASC, book.book_id ASC  []"},
+		{
+			name:     "cursor",
+			query:    url.Values{"cursor": {encodeCursor(cursor{Fields: []string{"id"}, Values: []string{"100"}})}},
+			expected: "ORDER BY book.book_id ASC WHERE ((book.book_id > $1)) [100]",
+		},
+		{
+			name:     "cursor with sort",
+			query:    url.Values{"sort": {"-numPages"}, "cursor": {encodeCursor(cursor{Fields: []string{"numPages", "id"}, Values: []string{"300", "7"}})}},
+			expected: "ORDER BY book.num_pages DESC, book.book_id ASC WHERE ((book.num_pages < $1) OR (book.num_pages = $1 AND book.book_id > $2)) [300 7]",
+		},
+		{
+			name:     "cursor for another sort",
+			query:    url.Values{"sort": {"title"}, "cursor": {encodeCursor(cursor{Fields: []string{"id"}, Values: []string{"100"}})}},
+			expected: "invalid cursor 'eyJmIjpbImlkIl0sInYiOlsiMTAwIl19' for sort fields [title id]",
+		},
+		{name: "malformed cursor", query: url.Values{"cursor": {"foo"}}, expected: "invalid cursor 'foo' for sort fields [id]"},
+		{
+			name:     "cursor with offset",
+			query:    url.Values{"offset": {"10"}, "cursor": {encodeCursor(cursor{Fields: []string{"id"}, Values: []string{"100"}})}},
+			expected: "cursor cannot be combined with offset",
+		},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		var where whereClause
+		orderBy, err := cursorOrderBy(c, &where, bookSortFields, "book.book_id")
+		if err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(fmt.Sprintf("%v %v %v", orderBy, where.sql(), where.args))
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+test.query.Encode(), nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		var where whereClause
+		if _, err := cursorOrderBy(c, &where, customerSortFields, "customer.customer_id"); err != nil {
+			return err
+		}
+		customers := []Customer{{Id: 1, LastName: "Zebra"}, {Id: 2, LastName: "Aardvark"}}
+
+		assert.Equal(t, "", nextCursor(c, customers))
+
+		c.Locals("morePages", true)
+		assert.Equal(t, encodeCursor(cursor{Fields: []string{"lastName", "id"}, Values: []string{"Aardvark", "2"}}), nextCursor(c, customers))
+		assert.Equal(t, "", nextCursor(c, []Customer{}))
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "/?sort=lastName", nil)
+	if _, err := app.Test(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/books?limit=10", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	first, _ := objx.FromJSON(string(body))
+
+	req, _ = http.NewRequest("GET", "/v1/books?limit=10&cursor="+first.Get("meta.pagination.nextCursor").Str(), nil)
+	resp, err = r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	second, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 11, second.Get("data[0].id").Int())
+	assert.False(t, second.Has("meta.pagination.total"))
+	assert.NotEmpty(t, second.Get("meta.pagination.nextCursor").Str())
+}
+
+// TestCursorPaginationLastPage checks that no cursor is returned for a last page that is exactly full
+// There are 4 shipping methods, so the second page of 2 is the last
+func TestCursorPaginationLastPage(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/shipping-methods?limit=2", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	first, _ := objx.FromJSON(string(body))
+
+	req, _ = http.NewRequest("GET", "/v1/shipping-methods?limit=2&cursor="+first.Get("meta.pagination.nextCursor").Str(), nil)
+	resp, err = r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	second, _ := objx.FromJSON(string(body))
+
+	assert.Len(t, second["data"], 2)
+	assert.False(t, second.Has("meta.pagination.nextCursor"))
+	assert.False(t, second.Has("meta.pagination.links.next"))
+}
+
+// TestCursorPaginationRelated checks that the related resource routes are paged by cursor too
+// Language 1 is English, which has far more than 10 books
+func TestCursorPaginationRelated(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/languages/1/books?limit=10", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	first, _ := objx.FromJSON(string(body))
+
+	req, _ = http.NewRequest("GET", "/v1/languages/1/books?limit=10&cursor="+first.Get("meta.pagination.nextCursor").Str(), nil)
+	resp, err = r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	second, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Greater(t, second.Get("data[0].id").Int(), first.Get("data[9].id").Int())
+	assert.NotEmpty(t, second.Get("meta.pagination.nextCursor").Str())
+}
@@ -15,11 +15,39 @@ type Customer struct {
 	Email     string `json:"email"`
 }
 
+// customerSortFields maps the sortable Customer fields to their columns
+var customerSortFields = map[string]string{
+	"id":        "customer.customer_id",
+	"firstName": "customer.first_name",
+	"lastName":  "customer.last_name",
+	"email":     "customer.email",
+}
+
+// customerFilterFields maps the Customer fields that can be used in filter[field][operator] query params to their columns
+var customerFilterFields = map[string]filterField{
+	"id":        {column: "customer.customer_id", kind: filterInt},
+	"firstName": {column: "customer.first_name", kind: filterText},
+	"lastName":  {column: "customer.last_name", kind: filterText},
+	"email":     {column: "customer.email", kind: filterText},
+}
+
 // AllCustomers returns all customers from the database as []Customer
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
 // []Customer is returned in all cases, so requires a check for error being nil
 func AllCustomers(db *pgx.Conn, c fiber.Ctx) ([]Customer, error) {
 	var customers []Customer
-	rows, err := db.Query(context.Background(), "SELECT * FROM customer LIMIT $1 OFFSET $2", c.Locals("limit"), c.Locals("offset"))
+	var where whereClause
+	if ferr := parseFilters(c, &where, customerFilterFields); ferr != nil {
+		return customers, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, customerSortFields, "customer.customer_id")
+	if ferr != nil {
+		return customers, ferr
+	}
+
+	sql := "SELECT * FROM customer " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return customers, err
 	}
@@ -40,23 +68,89 @@ func AllCustomers(db *pgx.Conn, c fiber.Ctx) ([]Customer, error) {
 	return customers, nil
 }
 
-// CustomersBySearchTerm returns []Customer from the database where searchTerm = searchValue
+// CustomerById returns a single Customer from the database with the given id
+// pgx.ErrNoRows is returned if no customer exists with that id
+func CustomerById(db *pgx.Conn, c fiber.Ctx, id int) (Customer, error) {
+	var cu Customer
+	err := db.QueryRow(context.Background(), "SELECT * FROM customer WHERE customer.customer_id=$1", id).
+		Scan(&cu.Id, &cu.FirstName, &cu.LastName, &cu.Email)
+	return cu, err
+}
+
+// CustomersByCountryId returns the customers with an address in the country with the given id as []Customer
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given, see customerSortFields
+// []Customer is returned in all cases, so requires a check for error being nil
+func CustomersByCountryId(db *pgx.Conn, c fiber.Ctx, id int) ([]Customer, error) {
+	var customers []Customer
+	var where whereClause
+	where.add(`EXISTS (
+		SELECT 1 FROM customer_address
+		JOIN address ON address.address_id = customer_address.address_id
+		WHERE customer_address.customer_id = customer.customer_id AND address.country_id = %s
+	)`, id)
+	if ferr := parseFilters(c, &where, customerFilterFields); ferr != nil {
+		return customers, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, customerSortFields, "customer.customer_id")
+	if ferr != nil {
+		return customers, ferr
+	}
+	rows, err := queryPage(db, c, "SELECT * FROM customer "+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return customers, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Customer
+		err := rows.Scan(&c.Id, &c.FirstName, &c.LastName, &c.Email)
+		if err != nil {
+			return customers, err
+		}
+		customers = append(customers, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return customers, err
+	}
+	return customers, nil
+}
+
+// CustomersBySearchTerm returns []Customer from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
 // To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
 // []Customer is returned in all cases, so requires a check for error being nil
-func CustomersBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerm, searchValue string) ([]Customer, error) {
+func CustomersBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Customer, error) {
 	var customers []Customer
-	var sql string
-	switch searchTerm {
-	case "email":
-		sql = "SELECT * from customer WHERE customer.email=$1 LIMIT $2 OFFSET $3"
-	default:
-		return customers, errors.New("invalid search term")
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "email":
+			if st.Match == matchFulltext {
+				return customers, unsupportedMatchError(st)
+			}
+			where.addSearchTerm("customer.email", st)
+		default:
+			return customers, errors.New("invalid search term")
+		}
 	}
 
-	rows, err := db.Query(context.Background(), sql, searchValue, c.Locals("limit"), c.Locals("offset"))
+	if ferr := parseFilters(c, &where, customerFilterFields); ferr != nil {
+		return customers, ferr
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, customerSortFields, "customer.customer_id")
+	if ferr != nil {
+		return customers, ferr
+	}
+
+	sql := "SELECT * FROM customer " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return customers, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var c Customer
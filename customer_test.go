@@ -59,7 +59,7 @@ func TestCustomerSearchError(t *testing.T) {
 	c := initRouter().AcquireCtx()
 	db.Close(context.Background())
 
-	res, err := CustomersBySearchTerm(db, c, "email", "rvatini1@fema.gov")
+	res, err := CustomersBySearchTerm(db, c, []SearchTerm{{Term: "email", Value: "rvatini1@fema.gov"}})
 
 	assert.Equal(t, []Customer(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
@@ -69,8 +69,29 @@ func TestCustomerSearchInvalidTerm(t *testing.T) {
 	var db *pgx.Conn
 	var c fiber.Ctx
 
-	res, err := CustomersBySearchTerm(db, c, "foo", "bar")
+	res, err := CustomersBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
 
 	assert.Equal(t, []Customer(nil), res)
 	assert.Equal(t, errors.New("invalid search term"), err)
 }
+
+func TestCustomerByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := CustomerById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestCustomersByCountryIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := CustomersByCountryId(db, c, 149)
+
+	assert.Equal(t, []Customer(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
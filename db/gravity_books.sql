@@ -18,6 +18,13 @@ SET xmloption = content;
 SET client_min_messages = warning;
 SET row_security = off;
 
+--
+-- Name: pg_trgm; Type: EXTENSION; Schema: -; Owner: -
+-- Provides trigram similarity for search suggestions
+--
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public;
+
 SET default_tablespace = '';
 
 SET default_table_access_method = heap;
@@ -92961,6 +92968,38 @@ ALTER TABLE ONLY public.cust_order
     ADD CONSTRAINT fk_order_ship FOREIGN KEY (shipping_method_id) REFERENCES public.shipping_method(method_id);
 
 
+--
+-- Name: book idx_book_title_fts; Type: INDEX; Schema: public; Owner: postgres
+-- Supports full-text search on book titles (match=fulltext)
+--
+
+CREATE INDEX idx_book_title_fts ON public.book USING gin (to_tsvector('english'::regconfig, (title)::text));
+
+
+--
+-- Name: author idx_author_name_fts; Type: INDEX; Schema: public; Owner: postgres
+-- Supports full-text search on author names (match=fulltext)
+--
+
+CREATE INDEX idx_author_name_fts ON public.author USING gin (to_tsvector('english'::regconfig, (author_name)::text));
+
+
+--
+-- Name: book idx_book_title_trgm; Type: INDEX; Schema: public; Owner: postgres
+-- Supports trigram similarity suggestions for book titles
+--
+
+CREATE INDEX idx_book_title_trgm ON public.book USING gin (title public.gin_trgm_ops);
+
+
+--
+-- Name: author idx_author_name_trgm; Type: INDEX; Schema: public; Owner: postgres
+-- Supports trigram similarity suggestions for author names
+--
+
+CREATE INDEX idx_author_name_trgm ON public.author USING gin (author_name public.gin_trgm_ops);
+
+
 -- Completed on 2024-01-24 11:14:12
 
 --
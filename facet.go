@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+)
+
+// FacetCount is the number of matching results that share a value, such as books in a language
+type FacetCount struct {
+	Value int    `json:"value"`
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// Facet is the counts for each value of a facet, which are only those with the highest counts if there are more than maxFacetValues
+type Facet struct {
+	Values    []FacetCount `json:"values"`
+	Truncated bool         `json:"truncated"` // Whether there are more values than maxFacetValues, so some were left out
+}
+
+// facetQuery is how the values of a facet are selected, with any join needed to get their labels
+// value and label must be whitelisted SQL, as they are not parameterised
+type facetQuery struct {
+	join  string
+	value string
+	label string
+}
+
+// maxFacetValues is the maximum number of values returned for each facet, taking those with the highest counts. Facets with more values are marked as truncated
+var maxFacetValues int = 20
+
+// parseFacets checks the comma separated 'facets' query param against validFacets, ignoring duplicates
+// Returns the requested facets and sets a c.Locals for HandleSearch and SendGravityResponse, or a *fiber.Error with a 400 status for an unknown facet
+func parseFacets(c fiber.Ctx, validFacets []string) ([]string, *fiber.Error) {
+	var facets []string
+	for _, facet := range strings.Split(c.Query("facets"), ",") {
+		facet = strings.TrimSpace(facet)
+		if facet == "" || slices.Contains(facets, facet) {
+			continue
+		}
+		if !slices.Contains(validFacets, facet) {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid facet '%v'. valid facets: %v", facet, validFacets))
+		}
+		facets = append(facets, facet)
+	}
+	c.Locals("facets", facets)
+	return facets, nil
+}
+
+// facetsParsed reports whether parseFacets has been called for the request, so that its facets param is handled
+func facetsParsed(c fiber.Ctx) bool {
+	_, ok := c.Locals("facets").([]string)
+	return ok
+}
+
+// queryFacets counts the distinct countColumn values for each value of the requested facets, within the rows selected by from and where
+// Counts are ordered highest first, and the results are keyed by facet name.
+// One more than maxFacetValues is selected, so that a facet with too many values can be marked as truncated
+func queryFacets(db *pgx.Conn, facets []string, facetQueries map[string]facetQuery, countColumn string, from string, where whereClause) (map[string]Facet, error) {
+	results := make(map[string]Facet)
+	for _, facet := range facets {
+		fq := facetQueries[facet]
+		sql := fmt.Sprintf("SELECT %s, %s, COUNT(DISTINCT %s) FROM %s %s %s GROUP BY 1, 2 ORDER BY 3 DESC, 1 LIMIT %d",
+			fq.value, fq.label, countColumn, from, fq.join, where.sql(), maxFacetValues+1)
+		rows, err := db.Query(context.Background(), sql, where.args...)
+		if err != nil {
+			return results, err
+		}
+
+		counts := []FacetCount{}
+		for rows.Next() {
+			var fc FacetCount
+			if err := rows.Scan(&fc.Value, &fc.Label, &fc.Count); err != nil {
+				rows.Close()
+				return results, err
+			}
+			counts = append(counts, fc)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return results, err
+		}
+
+		truncated := len(counts) > maxFacetValues
+		if truncated {
+			counts = counts[:maxFacetValues]
+		}
+		results[facet] = Facet{Values: counts, Truncated: truncated}
+	}
+	return results, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFacets(t *testing.T) {
+	var tests = []struct {
+		facets   string
+		expected string
+	}{
+		{facets: "", expected: ""},
+		{facets: "language", expected: "language"},
+		{facets: "decade, language,decade", expected: "decade,language"},
+		{facets: "language,author", expected: "invalid facet 'author'. valid facets: [language publisher decade]"},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		facets, err := parseFacets(c, validBookFacets)
+		if err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(strings.Join(facets, ","))
+	})
+
+	for _, test := range tests {
+		t.Run(test.facets, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?facets="+test.facets, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestFacetsParam(t *testing.T) {
+	var tests = []struct {
+		name           string
+		parseFacets    bool
+		search         bool
+		expectedStatus int
+		expectedDetail string
+	}{
+		{name: "facets list endpoint", parseFacets: true, expectedStatus: 200},
+		{name: "facets search endpoint", parseFacets: true, search: true, expectedStatus: 200},
+		{name: "other list endpoint", expectedStatus: 400, expectedDetail: "'facets' is not supported on this endpoint. facets are supported on the book list and search endpoints"},
+		{name: "other search endpoint", search: true, expectedStatus: 400, expectedDetail: "invalid search term 'facets'. valid search terms: [title]"},
+	}
+
+	search := func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Book, error) {
+		return []Book{{Id: 1}}, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c fiber.Ctx) error {
+				if test.parseFacets {
+					if _, err := parseFacets(c, validBookFacets); err != nil {
+						return err
+					}
+				}
+				data := []Book{{Id: 1}}
+				if test.search {
+					res, err := HandleSearch(nil, c, []string{"title"}, Book{}, search)
+					if err != nil {
+						return SendGravityResponse(c, &GravityResponse{Errors: []GravityError{{Status: fmt.Sprint(err.Code), Detail: err.Error()}}})
+					}
+					data = res
+				}
+				return SendGravityResponse(c, &GravityResponse{Data: data})
+			})
+
+			req, _ := http.NewRequest("GET", "/?title=Emma&facets=language", nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			res, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			if test.expectedDetail != "" {
+				assert.Equal(t, test.expectedDetail, res.Get("errors[0].detail").Str())
+			}
+		})
+	}
+}
+
+func TestBookFacets(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/books?facets=language,decade&filter[numPages][gte]=2000", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 1, a.Get("meta.facets.language.values[0].value").Int())
+	assert.Equal(t, "English", a.Get("meta.facets.language.values[0].label").Str())
+	assert.Equal(t, 2000, a.Get("meta.facets.decade.values[0].value").Int())
+	assert.Equal(t, "2000s", a.Get("meta.facets.decade.values[0].label").Str())
+	assert.Equal(t, 7, a.Get("meta.facets.decade.values[0].count").Int())
+	assert.False(t, a.Get("meta.facets.decade.truncated").Bool())
+	assert.False(t, a.Has("meta.facets.publisher"))
+
+	req, _ = http.NewRequest("GET", "/v1/books?facets=publisher", nil)
+	resp, err = r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ = objx.FromJSON(string(body))
+
+	assert.Len(t, a.Get("meta.facets.publisher.values").InterSlice(), maxFacetValues)
+	assert.True(t, a.Get("meta.facets.publisher.truncated").Bool())
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+var fieldsParamPattern = regexp.MustCompile(`^fields(?:\[(\w+)\])?$`)
+
+// parseFields checks the comma separated 'fields' query param, and the 'fields[type]' params for included resources, against validFields.
+// validFields maps each type to its fields, with the endpoint's own type under "" and included resources under their include name.
+// Sets a c.Locals for the model functions and SendGravityResponse if they are all valid, otherwise returns a *fiber.Error with a 400 status
+func parseFields(c fiber.Ctx, validFields map[string][]string) *fiber.Error {
+	fields := make(map[string][]string)
+	for k := range validFields {
+		fields[k] = nil
+	}
+
+	for param, value := range c.Queries() {
+		matches := fieldsParamPattern.FindStringSubmatch(param)
+		if matches == nil {
+			continue
+		}
+
+		fieldType := matches[1]
+		valid, ok := validFields[fieldType]
+		if !ok {
+			var validTypes []string
+			for k := range validFields {
+				if k != "" {
+					validTypes = append(validTypes, k)
+				}
+			}
+			sort.Strings(validTypes)
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid fields type '%v'. valid fields types: %v", fieldType, validTypes))
+		}
+
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" || slices.Contains(fields[fieldType], field) {
+				continue
+			}
+			if !slices.Contains(valid, field) {
+				return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid field '%v' in %v. valid fields: %v", field, param, valid))
+			}
+			fields[fieldType] = append(fields[fieldType], field)
+		}
+	}
+
+	c.Locals("fields", fields)
+	return nil
+}
+
+// fieldsParsed reports whether parseFields has been called for the request, so that its fields params are handled
+func fieldsParsed(c fiber.Ctx) bool {
+	_, ok := c.Locals("fields").(map[string][]string)
+	return ok
+}
+
+// unsupportedFieldsParam returns the first fields or fields[type] query param if parseFields hasn't been called for the request, so that it can be rejected
+func unsupportedFieldsParam(c fiber.Ctx) (string, bool) {
+	if fieldsParsed(c) {
+		return "", false
+	}
+	var params []string
+	for param := range c.Queries() {
+		if fieldsParamPattern.MatchString(param) {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return "", false
+	}
+	sort.Strings(params)
+	return params[0], true
+}
+
+// requestedFields returns the fields requested for the type, or nil if all fields should be returned
+// parseFields must have been called first, otherwise it always returns nil
+func requestedFields(c fiber.Ctx, fieldType string) []string {
+	fields, _ := c.Locals("fields").(map[string][]string)
+	return fields[fieldType]
+}
+
+// fieldColumn is the column that a field of a resource is selected from
+type fieldColumn struct {
+	field  string
+	column string
+}
+
+// includeFields returns the fields of an included resource to select, which are all of columns unless fewer were requested with ?fields[include]=
+// The id is always selected, as it is how the included resources are matched to the resources that include them
+func includeFields(c fiber.Ctx, include string, columns []fieldColumn) []string {
+	requested := requestedFields(c, include)
+	var fields []string
+	for _, fc := range columns {
+		if len(requested) == 0 || fc.field == "id" || slices.Contains(requested, fc.field) {
+			fields = append(fields, fc.field)
+		}
+	}
+	return fields
+}
+
+// selectColumns returns the columns of the given fields, in the order of columns, for a SELECT
+func selectColumns(columns []fieldColumn, fields []string) string {
+	var selected []string
+	for _, fc := range columns {
+		if slices.Contains(fields, fc.field) {
+			selected = append(selected, fc.column)
+		}
+	}
+	return strings.Join(selected, ", ")
+}
+
+// scanTargets returns the targets of the given fields, in the order of columns, for scanning a row selected with selectColumns
+func scanTargets(columns []fieldColumn, fields []string, targets map[string]any) []any {
+	var scan []any
+	for _, fc := range columns {
+		if slices.Contains(fields, fc.field) {
+			scan = append(scan, targets[fc.field])
+		}
+	}
+	return scan
+}
+
+// sparseData trims data to the fields requested by parseFields, for SendGravityResponse
+// data is returned unchanged if no fields were requested
+func sparseData(c fiber.Ctx, data interface{}) interface{} {
+	fields, ok := c.Locals("fields").(map[string][]string)
+	if !ok {
+		return data
+	}
+	requested := false
+	for _, f := range fields {
+		requested = requested || len(f) > 0
+	}
+	if !requested {
+		return data
+	}
+	return sparseValue(reflect.ValueOf(data), fields[""], fields)
+}
+
+// sparseValue returns v with only the given fields of each struct, keeping them in the struct's order.
+// Fields named after a type in nested are included resources, which are kept and trimmed to their own requested fields.
+// Fields that would be omitted by their omitempty JSON tag are still omitted
+func sparseValue(v reflect.Value, fields []string, nested map[string][]string) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = sparseValue(v.Index(i), fields, nested)
+		}
+		return values
+	case reflect.Struct:
+		var obj sparseObject
+		for i := 0; i < v.NumField(); i++ {
+			name, omitEmpty := jsonFieldName(v.Type().Field(i))
+			value := v.Field(i)
+			if name == "" || (omitEmpty && isEmptyValue(value)) {
+				continue
+			}
+
+			if nestedFields, ok := nested[name]; ok {
+				obj = append(obj, sparseField{key: name, value: sparseValue(value, nestedFields, nil)})
+			} else if len(fields) == 0 || slices.Contains(fields, name) {
+				obj = append(obj, sparseField{key: name, value: value.Interface()})
+			}
+		}
+		return obj
+	default:
+		return v.Interface()
+	}
+}
+
+// jsonFieldName returns the JSON name of a struct field and whether it is omitempty, or an empty name if it isn't marshalled
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		tag = f.Name
+	}
+	return tag, slices.Contains(strings.Split(opts, ","), "omitempty")
+}
+
+// isEmptyValue matches encoding/json's definition of empty for omitempty
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// sparseObject is a JSON object that keeps its fields in order, unlike a map
+type sparseObject []sparseField
+
+type sparseField struct {
+	key   string
+	value interface{}
+}
+
+func (o sparseObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
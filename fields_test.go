@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    url.Values
+		expected string
+	}{
+		{name: "no fields", query: url.Values{}, expected: "[] []"},
+		{name: "fields", query: url.Values{"fields": {"title, id,title"}}, expected: "[title id] []"},
+		{name: "include fields", query: url.Values{"fields": {"id"}, "fields[authors]": {"authorName"}}, expected: "[id] [authorName]"},
+		{name: "invalid field", query: url.Values{"fields": {"id,foo"}}, expected: "invalid field 'foo' in fields. valid fields: [id title isbn languageId numPages publicationDate publisherId rank]"},
+		{name: "invalid include field", query: url.Values{"fields[authors]": {"title"}}, expected: "invalid field 'title' in fields[authors]. valid fields: [id authorName]"},
+		{name: "invalid type", query: url.Values{"fields[foo]": {"id"}}, expected: "invalid fields type 'foo'. valid fields types: [authors language publisher]"},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		if err := parseFields(c, validBookFields); err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(fmt.Sprintf("%v %v", requestedFields(c, ""), requestedFields(c, "authors")))
+	})
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+test.query.Encode(), nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestSparseValue(t *testing.T) {
+	books := []Book{{
+		Id:        1,
+		Title:     "Emma",
+		Isbn:      "123",
+		Authors:   []Author{{Id: 2, AuthorName: "Jane Austen"}},
+		Publisher: &Publisher{Id: 3, PublisherName: "Penguin"},
+	}}
+
+	var tests = []struct {
+		name     string
+		fields   map[string][]string
+		expected string
+	}{
+		{
+			name:     "fields kept in struct order",
+			fields:   map[string][]string{"": {"title", "id"}, "authors": nil, "publisher": nil, "language": nil},
+			expected: `[{"id":1,"title":"Emma","authors":[{"id":2,"authorName":"Jane Austen"}],"publisher":{"id":3,"publisherName":"Penguin"}}]`,
+		},
+		{
+			name:     "include fields",
+			fields:   map[string][]string{"": {"isbn"}, "authors": {"authorName"}, "publisher": {"publisherName"}, "language": nil},
+			expected: `[{"isbn":"123","authors":[{"authorName":"Jane Austen"}],"publisher":{"publisherName":"Penguin"}}]`,
+		},
+		{
+			name:     "omitempty fields stay omitted",
+			fields:   map[string][]string{"": {"id", "rank"}, "authors": nil, "publisher": nil, "language": nil},
+			expected: `[{"id":1,"authors":[{"id":2,"authorName":"Jane Austen"}],"publisher":{"id":3,"publisherName":"Penguin"}}]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := json.Marshal(sparseValue(reflect.ValueOf(books), test.fields[""], test.fields))
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Equal(t, test.expected, string(b))
+		})
+	}
+}
+
+func TestSparseFieldsets(t *testing.T) {
+	r := initRouter()
+
+	req, _ := http.NewRequest("GET", "/v1/books?fields=id,title&include=authors&fields[authors]=authorName", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+	res, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.True(t, res.Has("data[0].id"))
+	assert.True(t, res.Has("data[0].title"))
+	assert.False(t, res.Has("data[0].isbn"))
+	assert.True(t, res.Has("data[0].authors[0].authorName"))
+	assert.False(t, res.Has("data[0].authors[0].id"))
+}
+
+func TestSearchFieldsParams(t *testing.T) {
+	var tests = []struct {
+		name        string
+		parseFields bool
+		query       string
+		expected    string
+	}{
+		{name: "fields endpoint", parseFields: true, query: "title=Emma&fields=id&fields[authors]=authorName", expected: "1"},
+		{name: "other endpoint", query: "title=Emma&fields=id", expected: "invalid search term 'fields'. valid search terms: [title]"},
+		{name: "other endpoint include fields", query: "title=Emma&fields[authors]=authorName", expected: "invalid search term 'fields[authors]'. valid search terms: [title]"},
+	}
+
+	search := func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Book, error) {
+		return []Book{{Id: 1}}, nil
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c fiber.Ctx) error {
+				if test.parseFields {
+					if err := parseFields(c, validBookFields); err != nil {
+						return c.SendString(err.Error())
+					}
+				}
+				res, err := HandleSearch(nil, c, []string{"title"}, Book{}, search)
+				if err != nil {
+					return c.SendString(err.Error())
+				}
+				return c.SendString(fmt.Sprint(len(res)))
+			})
+
+			req, _ := http.NewRequest("GET", "/?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestUnsupportedFieldsParams(t *testing.T) {
+	var tests = []struct {
+		name           string
+		parseFields    bool
+		query          string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "fields endpoint", parseFields: true, query: "fields=id", expectedStatus: 200},
+		{name: "other endpoint", query: "fields=bogus", expectedStatus: 400, expectedCode: "FIELDS-01"},
+		{name: "other endpoint include fields", query: "fields[publisher]=id", expectedStatus: 400, expectedCode: "FIELDS-01"},
+		{name: "other endpoint without fields", query: "limit=1", expectedStatus: 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c fiber.Ctx) error {
+				if test.parseFields {
+					if err := parseFields(c, validBookFields); err != nil {
+						return c.SendString(err.Error())
+					}
+				}
+				return SendGravityResponse(c, &GravityResponse{Data: []Book{{Id: 1}}})
+			})
+
+			req, _ := http.NewRequest("GET", "/?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			res, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedStatus, resp.StatusCode)
+			if test.expectedCode != "" {
+				assert.Equal(t, test.expectedCode, res.Get("errors[0].code").Str())
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// filterKind is the type of a filterable field, which decides how its values are parsed and which operators it supports
+type filterKind int
+
+const (
+	filterInt filterKind = iota
+	filterNumeric
+	filterText
+	filterDate
+)
+
+// filterField is the SQL column or expression a filterable API field maps to, along with its kind
+type filterField struct {
+	column string
+	kind   filterKind
+}
+
+// Filter operators, given as filter[field][operator]=value. A filter without an operator uses eq
+// in takes a comma separated list of values. contains and prefix are case-insensitive, and only supported by text fields
+const (
+	filterEq       = "eq"
+	filterNe       = "ne"
+	filterGt       = "gt"
+	filterGte      = "gte"
+	filterLt       = "lt"
+	filterLte      = "lte"
+	filterIn       = "in"
+	filterContains = "contains"
+	filterPrefix   = "prefix"
+)
+
+var validFilterOperators = []string{filterEq, filterNe, filterGt, filterGte, filterLt, filterLte, filterIn, filterContains, filterPrefix}
+
+// filterComparisons maps the comparison operators to their SQL
+var filterComparisons = map[string]string{
+	filterEq:  "=",
+	filterNe:  "<>",
+	filterGt:  ">",
+	filterGte: ">=",
+	filterLt:  "<",
+	filterLte: "<=",
+}
+
+var filterParamPattern = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+// parseFilters adds a condition to where for each filter[field][operator] query param, checking the field against filterFields
+// Values are parsed according to the field's kind and always passed as args, so only whitelisted columns reach the SQL.
+// A *fiber.Error with a 400 status is returned for a malformed filter, an unknown field or operator, or an invalid value
+func parseFilters(c fiber.Ctx, where *whereClause, filterFields map[string]filterField) *fiber.Error {
+	var params []string
+	for k := range c.Queries() {
+		if strings.HasPrefix(k, "filter[") {
+			params = append(params, k)
+		}
+	}
+	// Sorted so that the conditions, and so the placeholders, are in a consistent order
+	sort.Strings(params)
+
+	for _, param := range params {
+		matches := filterParamPattern.FindStringSubmatch(param)
+		if matches == nil {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter '%v', must be filter[field] or filter[field][operator]", param))
+		}
+
+		name, operator := matches[1], matches[2]
+		field, ok := filterFields[name]
+		if !ok {
+			var validFields []string
+			for k := range filterFields {
+				validFields = append(validFields, k)
+			}
+			sort.Strings(validFields)
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter field '%v'. valid filter fields: %v", name, validFields))
+		}
+		if operator == "" {
+			operator = filterEq
+		}
+
+		if err := addFilter(where, name, field, operator, c.Query(param)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addFilter adds the condition for a single filter to where
+func addFilter(where *whereClause, name string, field filterField, operator string, value string) *fiber.Error {
+	switch operator {
+	case filterIn:
+		var values []any
+		for _, v := range strings.Split(value, ",") {
+			parsed, err := parseFilterValue(name, field, strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			values = append(values, parsed)
+		}
+		where.add(field.column+" = ANY(%s"+filterCast(field, true)+")", filterArray(field, values))
+	case filterContains, filterPrefix:
+		if field.kind != filterText {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("filter operator '%v' is not supported for filter field '%v'", operator, name))
+		}
+		pattern := escapeLike(value) + "%"
+		if operator == filterContains {
+			pattern = "%" + pattern
+		}
+		where.add(field.column+` ILIKE %s ESCAPE '\'`, pattern)
+	default:
+		comparison, ok := filterComparisons[operator]
+		if !ok {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter operator '%v'. valid filter operators: %v", operator, validFilterOperators))
+		}
+		parsed, err := parseFilterValue(name, field, value)
+		if err != nil {
+			return err
+		}
+		where.add(field.column+" "+comparison+" %s"+filterCast(field, false), parsed)
+	}
+
+	return nil
+}
+
+// parseFilterValue parses a single filter value according to the field's kind
+// Numeric values are checked but kept as strings, to be cast by Postgres without losing precision
+func parseFilterValue(name string, field filterField, value string) (any, *fiber.Error) {
+	switch field.kind {
+	case filterInt:
+		i, err := parseInt32(value)
+		if err != nil {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter value '%v' for '%v', must be an integer", value, name))
+		}
+		return i, nil
+	case filterNumeric:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter value '%v' for '%v', must be a number", value, name))
+		}
+		return value, nil
+	case filterDate:
+		d, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid filter value '%v' for '%v', must be a date (YYYY-MM-DD)", value, name))
+		}
+		return d, nil
+	default:
+		return value, nil
+	}
+}
+
+// filterArray converts the parsed values of an in filter to a typed slice, so that pgx can encode it as a Postgres array
+func filterArray(field filterField, values []any) any {
+	switch field.kind {
+	case filterInt:
+		return convertFilterValues[int](values)
+	case filterDate:
+		return convertFilterValues[time.Time](values)
+	default:
+		return convertFilterValues[string](values)
+	}
+}
+
+// convertFilterValues converts []any to []t, where every value is already known to be a t
+func convertFilterValues[t any](values []any) []t {
+	converted := make([]t, len(values))
+	for i, v := range values {
+		converted[i] = v.(t)
+	}
+	return converted
+}
+
+// filterCast returns the cast needed for a placeholder of the field's kind, as numeric values are passed as strings
+// Arrays are cast through text[], as pgx sends arrays in binary format and can't encode a []string as a numeric[]
+func filterCast(field filterField, array bool) string {
+	if field.kind != filterNumeric {
+		return ""
+	}
+	if array {
+		return "::text[]::numeric[]"
+	}
+	return "::numeric"
+}
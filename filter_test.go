@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilters(t *testing.T) {
+	var tests = []struct {
+		query    string
+		expected string
+	}{
+		{query: "", expected: " []"},
+		{query: "filter[numPages][gte]=100", expected: "WHERE book.num_pages >= $1 [100]"},
+		{query: "filter[title]=Emma", expected: "WHERE book.title = $1 [Emma]"},
+		{query: "filter[languageId][in]=1,2&filter[numPages][lt]=300", expected: "WHERE book.language_id = ANY($1) AND book.num_pages < $2 [[1 2] 300]"},
+		{query: "filter[title][contains]=100%25", expected: `WHERE book.title ILIKE $1 ESCAPE '\' [%100\%%]`},
+		{query: "filter[title][prefix]=Emma", expected: `WHERE book.title ILIKE $1 ESCAPE '\' [Emma%]`},
+		{query: "filter[publicationDate][lt]=2000-01-01", expected: "WHERE book.publication_date < $1 [2000-01-01 00:00:00 +0000 UTC]"},
+		{query: "filter[foo]=1", expected: "invalid filter field 'foo'. valid filter fields: [id isbn languageId numPages publicationDate publisherId title]"},
+		{query: "filter[numPages][between]=1", expected: "invalid filter operator 'between'. valid filter operators: [eq ne gt gte lt lte in contains prefix]"},
+		{query: "filter[numPages][contains]=1", expected: "filter operator 'contains' is not supported for filter field 'numPages'"},
+		{query: "filter[numPages]=many", expected: "invalid filter value 'many' for 'numPages', must be an integer"},
+		{query: "filter[numPages]=99999999999", expected: "invalid filter value '99999999999' for 'numPages', must be an integer"},
+		{query: "filter[languageId][in]=1,x", expected: "invalid filter value 'x' for 'languageId', must be an integer"},
+		{query: "filter[publicationDate]=2000", expected: "invalid filter value '2000' for 'publicationDate', must be a date (YYYY-MM-DD)"},
+		{query: "filter[numPages][gte][x]=1", expected: "invalid filter 'filter[numPages][gte][x]', must be filter[field] or filter[field][operator]"},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		var where whereClause
+		if err := parseFilters(c, &where, bookFilterFields); err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(fmt.Sprintf("%v %v", where.sql(), where.args))
+	})
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestParseFiltersNumeric(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		var where whereClause
+		if err := parseFilters(c, &where, shippingMethodFilterFields); err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(fmt.Sprintf("%v %v", where.sql(), where.args))
+	})
+
+	var tests = []struct {
+		query    string
+		expected string
+	}{
+		{query: "filter[cost][lte]=8.90", expected: "WHERE shipping_method.cost <= $1::numeric [8.90]"},
+		{query: "filter[cost][in]=5.90,8.90", expected: "WHERE shipping_method.cost = ANY($1::text[]::numeric[]) [[5.90 8.90]]"},
+		{query: "filter[cost]=cheap", expected: "invalid filter value 'cheap' for 'cost', must be a number"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+// TestFilterArrayEncoding checks that pgx can encode the values of each kind of in filter as the array type of its placeholder
+func TestFilterArrayEncoding(t *testing.T) {
+	var tests = []struct {
+		name     string
+		field    filterField
+		values   []any
+		arrayOID uint32
+	}{
+		{name: "int", field: filterField{kind: filterInt}, values: []any{1, 2}, arrayOID: pgtype.Int8ArrayOID},
+		{name: "numeric", field: filterField{kind: filterNumeric}, values: []any{"5.90", "8.90"}, arrayOID: pgtype.TextArrayOID},
+		{name: "text", field: filterField{kind: filterText}, values: []any{"Emma"}, arrayOID: pgtype.TextArrayOID},
+		{name: "date", field: filterField{kind: filterDate}, values: []any{time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}, arrayOID: pgtype.DateArrayOID},
+	}
+
+	m := pgtype.NewMap()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := m.Encode(test.arrayOID, pgtype.BinaryFormatCode, filterArray(test.field, test.values), nil)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// parseIncludes checks the comma separated 'include' query param against validIncludes
+// Sets a c.Locals for the model functions if they are all valid, otherwise returns a *fiber.Error with a 400 status
+func parseIncludes(c fiber.Ctx, validIncludes []string) *fiber.Error {
+	var includes []string
+
+	for _, include := range strings.Split(c.Query("include"), ",") {
+		include = strings.TrimSpace(include)
+		if include == "" {
+			continue
+		}
+		if !slices.Contains(validIncludes, include) {
+			return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid include '%v'. valid includes: %v", include, validIncludes))
+		}
+		includes = append(includes, include)
+	}
+
+	c.Locals("include", includes)
+	return nil
+}
+
+// hasInclude returns true if the related resource was requested by the 'include' query param
+// parseIncludes must have been called first, otherwise it always returns false
+func hasInclude(c fiber.Ctx, include string) bool {
+	includes, ok := c.Locals("include").([]string)
+	return ok && slices.Contains(includes, include)
+}
+
+// includer is a resource with related resources that can be embedded with the 'include' query param
+type includer interface {
+	validIncludes() []string
+}
+
+var includerType = reflect.TypeOf((*includer)(nil)).Elem()
+
+// validIncludesOf returns the related resources that can be embedded in resources of type t, or nil if it has none
+func validIncludesOf(t reflect.Type) []string {
+	if !t.Implements(includerType) {
+		return nil
+	}
+	return reflect.Zero(t).Interface().(includer).validIncludes()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+)
+
+type Language struct {
+	Id           int    `json:"id"`
+	LanguageCode string `json:"languageCode"`
+	LanguageName string `json:"languageName"`
+}
+
+// languageSortFields maps the sortable Language fields to their columns
+var languageSortFields = map[string]string{
+	"id":           "book_language.language_id",
+	"languageCode": "book_language.language_code",
+	"languageName": "book_language.language_name",
+}
+
+// languageFilterFields maps the Language fields that can be used in filter[field][operator] query params to their columns
+var languageFilterFields = map[string]filterField{
+	"id":           {column: "book_language.language_id", kind: filterInt},
+	"languageCode": {column: "book_language.language_code", kind: filterText},
+	"languageName": {column: "book_language.language_name", kind: filterText},
+}
+
+// AllLanguages returns all languages from the database as []Language
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
+// []Language is returned in all cases, so requires a check for error being nil
+func AllLanguages(db *pgx.Conn, c fiber.Ctx) ([]Language, error) {
+	var languages []Language
+	var where whereClause
+	if ferr := parseFilters(c, &where, languageFilterFields); ferr != nil {
+		return languages, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, languageSortFields, "book_language.language_id")
+	if ferr != nil {
+		return languages, ferr
+	}
+
+	sql := "SELECT * FROM book_language " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return languages, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l Language
+		err := rows.Scan(&l.Id, &l.LanguageCode, &l.LanguageName)
+		if err != nil {
+			return languages, err
+		}
+		languages = append(languages, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return languages, err
+	}
+	return languages, nil
+}
+
+// LanguageById returns a single Language from the database with the given id
+// pgx.ErrNoRows is returned if no language exists with that id
+func LanguageById(db *pgx.Conn, c fiber.Ctx, id int) (Language, error) {
+	var l Language
+	err := db.QueryRow(context.Background(), "SELECT * FROM book_language WHERE book_language.language_id=$1", id).Scan(&l.Id, &l.LanguageCode, &l.LanguageName)
+	return l, err
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLanguageById(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/languages/1", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, "eng", a.Get("data.languageCode").Str())
+	assert.Equal(t, "English", a.Get("data.languageName").Str())
+}
+
+func TestLanguageBooks(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/languages/2/books?limit=1", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 1, a.Get("data[0].id").Int())
+	assert.Equal(t, 2, a.Get("data[0].languageId").Int())
+}
+
+func TestAllLanguagesError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := AllLanguages(db, c)
+
+	assert.Equal(t, []Language(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestLanguageByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := LanguageById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+)
+
+// HandleLookup gives us a generic way to retrieve a single model by the :id route param without repeating the checks in each handler.
+// It takes a lookup function, then returns the resulting model, or a *fiber.Error with a 400 for a non integer id and a 404 for a missing one
+func HandleLookup[m any](db *pgx.Conn, c fiber.Ctx, lookupFunc func(db *pgx.Conn, c fiber.Ctx, id int) (m, error)) (m, *fiber.Error) {
+	var result m
+
+	id, ferr := parseIdParam(c)
+	if ferr != nil {
+		return result, ferr
+	}
+
+	result, err := lookupFunc(db, c, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return result, fiber.NewError(fiber.ErrNotFound.Code, fmt.Sprintf("no result found with id %d", id))
+	}
+	if err != nil {
+		return result, fiber.NewError(fiber.ErrInternalServerError.Code, fmt.Sprintf("error retrieving by id %v", err.Error()))
+	}
+
+	return result, nil
+}
+
+// HandleRelated gives us a generic way to retrieve the models related to a parent given by the :id route param.
+// parentFunc is only used to check the parent exists, so that a missing parent is a 404 rather than an empty list
+func HandleRelated[p any, m any](db *pgx.Conn, c fiber.Ctx, parentFunc func(db *pgx.Conn, c fiber.Ctx, id int) (p, error), relatedFunc func(db *pgx.Conn, c fiber.Ctx, id int) ([]m, error)) ([]m, *fiber.Error) {
+	var results []m
+
+	if _, ferr := HandleLookup(db, c, parentFunc); ferr != nil {
+		return results, ferr
+	}
+
+	id, _ := parseIdParam(c)
+	results, err := relatedFunc(db, c, id)
+	// Related functions return a *fiber.Error for problems with the request itself, such as an invalid sort field
+	var ferr *fiber.Error
+	if errors.As(err, &ferr) {
+		return results, ferr
+	}
+	if err != nil {
+		return results, fiber.NewError(fiber.ErrInternalServerError.Code, fmt.Sprintf("error retrieving related results %v", err.Error()))
+	}
+
+	return results, nil
+}
+
+// parseIdParam returns the :id route param as an int
+// A *fiber.Error with a 400 status is returned if it is not an integer, or too large to be an id
+func parseIdParam(c fiber.Ctx) (int, *fiber.Error) {
+	id, err := parseInt32(c.Params("id"))
+	if err != nil {
+		return 0, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid id '%v', id must be an integer", c.Params("id")))
+	}
+
+	return id, nil
+}
+
+// parseInt32 parses s as an integer that fits in a Postgres integer column, such as an id.
+// Larger values would only fail once they reach the query, so are treated as invalid here instead
+func parseInt32(s string) (int, error) {
+	i, err := strconv.ParseInt(s, 10, 32)
+	return int(i), err
+}
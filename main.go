@@ -5,7 +5,6 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"strconv"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/gofiber/template/html/v2"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 )
 
@@ -34,7 +34,9 @@ func initRouter() *fiber.App {
 	r := fiber.New(fiber.Config{AppName: "Gravity API", Views: templateEngine})
 	r.Use(logger.New())
 	r.Use(parseLimitOffset)
+	r.Use(parseFormat)
 	db := connectToDb()
+	pool := connectToPool()
 
 	r.Get("/", func(c fiber.Ctx) error {
 		return c.Render("index", fiber.Map{"routes": r.GetRoutes()})
@@ -45,34 +47,103 @@ func initRouter() *fiber.App {
 	})
 
 	v1 := r.Group("/v1")
+	v1.Get("/search", func(c fiber.Ctx) error {
+		return handleUnifiedSearch(c, pool)
+	})
 	v1.Get("/countries", func(c fiber.Ctx) error {
 		return handleAllCountries(c, db)
 	})
+	v1.Get("/countries/search", func(c fiber.Ctx) error {
+		return handleCountriesSearch(c, db)
+	})
+	v1.Get("/countries/:id", func(c fiber.Ctx) error {
+		return handleCountry(c, db)
+	})
+	v1.Get("/countries/:id/customers", func(c fiber.Ctx) error {
+		return handleCountryCustomers(c, db)
+	})
+	v1.Get("/countries/:id/orders", func(c fiber.Ctx) error {
+		return handleCountryOrders(c, db)
+	})
 	v1.Get("/authors", func(c fiber.Ctx) error {
 		return handleAllAuthors(c, db)
 	})
 	v1.Get("/authors/search", func(c fiber.Ctx) error {
 		return handleAuthorsSearch(c, db)
 	})
+	v1.Get("/authors/:id", func(c fiber.Ctx) error {
+		return handleAuthor(c, db)
+	})
+	v1.Get("/authors/:id/books", func(c fiber.Ctx) error {
+		return handleAuthorBooks(c, db)
+	})
 	v1.Get("/books", func(c fiber.Ctx) error {
 		return handleAllBooks(c, db)
 	})
 	v1.Get("/books/search", func(c fiber.Ctx) error {
 		return handleBooksSearch(c, db)
 	})
+	v1.Get("/books/:id", func(c fiber.Ctx) error {
+		return handleBook(c, db)
+	})
 	v1.Get("/customers", func(c fiber.Ctx) error {
 		return handleAllCustomers(c, db)
 	})
 	v1.Get("/customers/search", func(c fiber.Ctx) error {
 		return handleCustomersSearch(c, db)
 	})
+	v1.Get("/customers/:id", func(c fiber.Ctx) error {
+		return handleCustomer(c, db)
+	})
+	v1.Get("/customers/:id/addresses", func(c fiber.Ctx) error {
+		return handleCustomerAddresses(c, db)
+	})
 
+	v1.Get("/languages", func(c fiber.Ctx) error {
+		return handleAllLanguages(c, db)
+	})
+	v1.Get("/languages/:id", func(c fiber.Ctx) error {
+		return handleLanguage(c, db)
+	})
+	v1.Get("/languages/:id/books", func(c fiber.Ctx) error {
+		return handleLanguageBooks(c, db)
+	})
+	v1.Get("/orders", func(c fiber.Ctx) error {
+		return handleAllOrders(c, db)
+	})
+	v1.Get("/orders/search", func(c fiber.Ctx) error {
+		return handleOrdersSearch(c, db)
+	})
+	v1.Get("/orders/:id", func(c fiber.Ctx) error {
+		return handleOrder(c, db)
+	})
+	v1.Get("/orders/:id/lines", func(c fiber.Ctx) error {
+		return handleOrderLines(c, db)
+	})
+	v1.Get("/orders/:id/history", func(c fiber.Ctx) error {
+		return handleOrderHistory(c, db)
+	})
 	v1.Get("/publishers", func(c fiber.Ctx) error {
 		return handleAllPublishers(c, db)
 	})
+	v1.Get("/publishers/search", func(c fiber.Ctx) error {
+		return handlePublishersSearch(c, db)
+	})
+	v1.Get("/publishers/:id", func(c fiber.Ctx) error {
+		return handlePublisher(c, db)
+	})
+	v1.Get("/publishers/:id/books", func(c fiber.Ctx) error {
+		return handlePublisherBooks(c, db)
+	})
 	v1.Get("/shipping-methods", func(c fiber.Ctx) error {
 		return handleAllShippingMethods(c, db)
 	})
+	v1.Get("/shipping-methods/search", func(c fiber.Ctx) error {
+		return handleShippingMethodsSearch(c, db)
+	})
+	v1.Get("/shipping-methods/:id", func(c fiber.Ctx) error {
+		return handleShippingMethod(c, db)
+	})
 
 	return r
 }
@@ -103,6 +174,36 @@ func connectToDb() *pgx.Conn {
 	return conn
 }
 
+// connectToPool returns a pool of connections to the same database as connectToDb, for handlers that run queries concurrently
+// Connections are opened as they are needed, and reused between requests. connectToDb must be called first to read the env file
+func connectToPool() *pgxpool.Pool {
+	pool, err := pgxpool.New(context.Background(), os.Getenv("GRAVITY_API_DB_CONNECTION_STRING"))
+	if err != nil {
+		log.Printf("Unable to create database pool: %v\n", err)
+	}
+
+	return pool
+}
+
+// /v1/search
+
+// handleUnifiedSearch handles GET /v1/search?q=<query>&types=<type>,<type>
+// Results are grouped by type, see HandleUnifiedSearch for the valid types
+func handleUnifiedSearch(c fiber.Ctx, pool *pgxpool.Pool) error {
+	res, err := HandleUnifiedSearch(pool, c)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "SEARCH-01",
+			Title:  "Error searching",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: res})
+}
+
 // /v1/countries
 
 // handleAllCountries handles GET /v1/countries
@@ -110,7 +211,7 @@ func handleAllCountries(c fiber.Ctx, db *pgx.Conn) error {
 	countries, err := AllCountries(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "COUNTRIES-01",
 			Title:  "Error retrieving countries",
 			Detail: err.Error(),
@@ -121,6 +222,73 @@ func handleAllCountries(c fiber.Ctx, db *pgx.Conn) error {
 	return SendGravityResponse(c, &GravityResponse{Data: countries})
 }
 
+// handleCountriesSearch handles GET /v1/countries/search?<searchTerm>=<searchValue>
+// Valid query params for search terms are defined within the function
+func handleCountriesSearch(c fiber.Ctx, db *pgx.Conn) error {
+	var validCountrySearchTerms = []string{"name"}
+
+	res, err := HandleSearch(db, c, validCountrySearchTerms, Country{}, CountriesBySearchTerm)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "COUNTRIES-05",
+			Title:  "Error searching countries",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: res})
+}
+
+// handleCountry handles GET /v1/countries/:id
+func handleCountry(c fiber.Ctx, db *pgx.Conn) error {
+	country, err := HandleLookup(db, c, CountryById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "COUNTRIES-02",
+			Title:  "Error retrieving country",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: country})
+}
+
+// handleCountryCustomers handles GET /v1/countries/:id/customers
+func handleCountryCustomers(c fiber.Ctx, db *pgx.Conn) error {
+	customers, err := HandleRelated(db, c, CountryById, CustomersByCountryId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "COUNTRIES-03",
+			Title:  "Error retrieving country customers",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: customers})
+}
+
+// handleCountryOrders handles GET /v1/countries/:id/orders
+func handleCountryOrders(c fiber.Ctx, db *pgx.Conn) error {
+	orders, err := HandleRelated(db, c, CountryById, OrdersByCountryId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "COUNTRIES-04",
+			Title:  "Error retrieving country orders",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: orders})
+}
+
 // /v1/authors
 
 // handleAllAuthors handles GET /v1/authors
@@ -129,7 +297,7 @@ func handleAllAuthors(c fiber.Ctx, db *pgx.Conn) error {
 	authors, err := AllAuthors(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "AUTHORS-01",
 			Title:  "Error retrieving authors",
 			Detail: err.Error(),
@@ -152,6 +320,7 @@ func handleAuthorsSearch(c fiber.Ctx, db *pgx.Conn) error {
 			Code:   "AUTHORS-02",
 			Title:  "Error searching authors",
 			Detail: err.Error(),
+			Meta:   searchSuggestions(db, c, err, authorSuggestionSources),
 		}}}
 		return SendGravityResponse(c, errorRes)
 	}
@@ -159,14 +328,72 @@ func handleAuthorsSearch(c fiber.Ctx, db *pgx.Conn) error {
 	return SendGravityResponse(c, &GravityResponse{Data: res})
 }
 
+// handleAuthor handles GET /v1/authors/:id
+func handleAuthor(c fiber.Ctx, db *pgx.Conn) error {
+	author, err := HandleLookup(db, c, AuthorById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "AUTHORS-03",
+			Title:  "Error retrieving author",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: author})
+}
+
+// handleAuthorBooks handles GET /v1/authors/:id/books
+func handleAuthorBooks(c fiber.Ctx, db *pgx.Conn) error {
+	books, err := HandleRelated(db, c, AuthorById, BooksByAuthorId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "AUTHORS-04",
+			Title:  "Error retrieving author books",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: books})
+}
+
 // /v1/books
 
 // handleAllBooks handles GET /v1/books
+// Valid includes, fields, range terms and facets are defined in book.go. Facet counts are added to the meta if requested with ?facets=
 func handleAllBooks(c fiber.Ctx, db *pgx.Conn) error {
+	ferr := parseIncludes(c, validBookIncludes)
+	if ferr == nil {
+		ferr = parseFields(c, validBookFields)
+	}
+	if ferr != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(ferr.Code),
+			Code:   "BOOKS-01",
+			Title:  "Error retrieving books",
+			Detail: ferr.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	facets, ferr := parseFacets(c, validBookFacets)
+	if ferr != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(ferr.Code),
+			Code:   "BOOKS-01",
+			Title:  "Error retrieving books",
+			Detail: ferr.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
 	books, err := AllBooks(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "BOOKS-01",
 			Title:  "Error retrieving books",
 			Detail: err.Error(),
@@ -174,13 +401,42 @@ func handleAllBooks(c fiber.Ctx, db *pgx.Conn) error {
 		return SendGravityResponse(c, errorRes)
 	}
 
-	return SendGravityResponse(c, &GravityResponse{Data: books})
+	res := &GravityResponse{Data: books}
+	if len(facets) > 0 {
+		counts, err := BookFacets(db, c, bookRangeSearchTerms(c), facets)
+		if err != nil {
+			errorRes := &GravityResponse{Errors: []GravityError{{
+				Status: fmt.Sprint(errorStatus(err)),
+				Code:   "BOOKS-01",
+				Title:  "Error retrieving books",
+				Detail: err.Error(),
+			}}}
+			return SendGravityResponse(c, errorRes)
+		}
+		res.Meta.Facets = counts
+	}
+
+	return SendGravityResponse(c, res)
 }
 
 // handleBooksSearch handles GET /v1/books/search?<searchTerm>=<searchValue>
-// Valid query params for search terms are defined within the function
+// Valid query params for search terms are defined within the function. Facet counts are added to the meta if requested with ?facets=
 func handleBooksSearch(c fiber.Ctx, db *pgx.Conn) error {
-	var validBookSearchTerms = []string{"title", "isbn", "author"}
+	var validBookSearchTerms = append([]string{"title", "isbn", "author"}, bookRangeTerms...)
+
+	facets, ferr := parseFacets(c, validBookFacets)
+	if ferr == nil {
+		ferr = parseFields(c, validBookFields)
+	}
+	if ferr != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(ferr.Code),
+			Code:   "BOOKS-02",
+			Title:  "Error searching books",
+			Detail: ferr.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
 
 	res, err := HandleSearch(db, c, validBookSearchTerms, Book{}, BooksBySearchTerm)
 	if err != nil {
@@ -189,11 +445,51 @@ func handleBooksSearch(c fiber.Ctx, db *pgx.Conn) error {
 			Code:   "BOOKS-02",
 			Title:  "Error searching books",
 			Detail: err.Error(),
+			Meta:   searchSuggestions(db, c, err, bookSuggestionSources),
 		}}}
 		return SendGravityResponse(c, errorRes)
 	}
 
-	return SendGravityResponse(c, &GravityResponse{Data: res})
+	searchRes := &GravityResponse{Data: res}
+	if len(facets) > 0 {
+		counts, err := BookFacets(db, c, searchTermsFromQuery(c, validBookSearchTerms), facets)
+		if err != nil {
+			errorRes := &GravityResponse{Errors: []GravityError{{
+				Status: fmt.Sprint(errorStatus(err)),
+				Code:   "BOOKS-02",
+				Title:  "Error searching books",
+				Detail: err.Error(),
+			}}}
+			return SendGravityResponse(c, errorRes)
+		}
+		searchRes.Meta.Facets = counts
+	}
+
+	return SendGravityResponse(c, searchRes)
+}
+
+// handleBook handles GET /v1/books/:id
+// Valid includes are defined in book.go
+func handleBook(c fiber.Ctx, db *pgx.Conn) error {
+	var book Book
+	err := parseIncludes(c, validBookIncludes)
+	if err == nil {
+		err = parseFields(c, validBookFields)
+	}
+	if err == nil {
+		book, err = HandleLookup(db, c, BookById)
+	}
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "BOOKS-03",
+			Title:  "Error retrieving book",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: book})
 }
 
 // /v1/customers
@@ -203,7 +499,7 @@ func handleAllCustomers(c fiber.Ctx, db *pgx.Conn) error {
 	customers, err := AllCustomers(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "CUSTOMERS-01",
 			Title:  "Error retrieving customers",
 			Detail: err.Error(),
@@ -233,6 +529,189 @@ func handleCustomersSearch(c fiber.Ctx, db *pgx.Conn) error {
 	return SendGravityResponse(c, &GravityResponse{Data: res})
 }
 
+// handleCustomer handles GET /v1/customers/:id
+func handleCustomer(c fiber.Ctx, db *pgx.Conn) error {
+	customer, err := HandleLookup(db, c, CustomerById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "CUSTOMERS-03",
+			Title:  "Error retrieving customer",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: customer})
+}
+
+// handleCustomerAddresses handles GET /v1/customers/:id/addresses
+func handleCustomerAddresses(c fiber.Ctx, db *pgx.Conn) error {
+	addresses, err := HandleRelated(db, c, CustomerById, AddressesByCustomerId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "CUSTOMERS-04",
+			Title:  "Error retrieving customer addresses",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: addresses})
+}
+
+// /v1/languages
+
+// handleAllLanguages handles GET /v1/languages
+func handleAllLanguages(c fiber.Ctx, db *pgx.Conn) error {
+	languages, err := AllLanguages(db, c)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(errorStatus(err)),
+			Code:   "LANGUAGES-01",
+			Title:  "Error retrieving languages",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: languages})
+}
+
+// handleLanguage handles GET /v1/languages/:id
+func handleLanguage(c fiber.Ctx, db *pgx.Conn) error {
+	language, err := HandleLookup(db, c, LanguageById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "LANGUAGES-02",
+			Title:  "Error retrieving language",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: language})
+}
+
+// handleLanguageBooks handles GET /v1/languages/:id/books
+func handleLanguageBooks(c fiber.Ctx, db *pgx.Conn) error {
+	books, err := HandleRelated(db, c, LanguageById, BooksByLanguageId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "LANGUAGES-03",
+			Title:  "Error retrieving language books",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: books})
+}
+
+// /v1/orders
+
+// handleAllOrders handles GET /v1/orders
+// Valid includes are defined in order.go
+func handleAllOrders(c fiber.Ctx, db *pgx.Conn) error {
+	if err := parseIncludes(c, validOrderIncludes); err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "ORDERS-01",
+			Title:  "Error retrieving orders",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	orders, err := AllOrders(db, c)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(errorStatus(err)),
+			Code:   "ORDERS-01",
+			Title:  "Error retrieving orders",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: orders})
+}
+
+// handleOrdersSearch handles GET /v1/orders/search?<searchTerm>=<searchValue>
+// Valid query params for search terms are defined within the function
+func handleOrdersSearch(c fiber.Ctx, db *pgx.Conn) error {
+	var validOrderSearchTerms = []string{"customerId", "shippingMethodId", "city", "country", "status"}
+
+	res, err := HandleSearch(db, c, validOrderSearchTerms, Order{}, OrdersBySearchTerm)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "ORDERS-05",
+			Title:  "Error searching orders",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: res})
+}
+
+// handleOrder handles GET /v1/orders/:id
+// Valid includes are defined in order.go
+func handleOrder(c fiber.Ctx, db *pgx.Conn) error {
+	var order Order
+	err := parseIncludes(c, validOrderIncludes)
+	if err == nil {
+		order, err = HandleLookup(db, c, OrderById)
+	}
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "ORDERS-02",
+			Title:  "Error retrieving order",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: order})
+}
+
+// handleOrderLines handles GET /v1/orders/:id/lines
+func handleOrderLines(c fiber.Ctx, db *pgx.Conn) error {
+	lines, err := HandleRelated(db, c, OrderById, OrderLinesByOrderId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "ORDERS-03",
+			Title:  "Error retrieving order lines",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: lines})
+}
+
+// handleOrderHistory handles GET /v1/orders/:id/history
+func handleOrderHistory(c fiber.Ctx, db *pgx.Conn) error {
+	history, err := HandleRelated(db, c, OrderById, OrderHistoryByOrderId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "ORDERS-04",
+			Title:  "Error retrieving order history",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: history})
+}
+
 // /v1/publishers
 
 // handleAllPublishers handles GET /v1/publishers
@@ -240,7 +719,7 @@ func handleAllPublishers(c fiber.Ctx, db *pgx.Conn) error {
 	publishers, err := AllPublishers(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "PUBLISHERS-01",
 			Title:  "Error retrieving publishers",
 			Detail: err.Error(),
@@ -251,6 +730,57 @@ func handleAllPublishers(c fiber.Ctx, db *pgx.Conn) error {
 	return SendGravityResponse(c, &GravityResponse{Data: publishers})
 }
 
+// handlePublishersSearch handles GET /v1/publishers/search?<searchTerm>=<searchValue>
+// Valid query params for search terms are defined within the function
+func handlePublishersSearch(c fiber.Ctx, db *pgx.Conn) error {
+	var validPublisherSearchTerms = []string{"name"}
+
+	res, err := HandleSearch(db, c, validPublisherSearchTerms, Publisher{}, PublishersBySearchTerm)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "PUBLISHERS-04",
+			Title:  "Error searching publishers",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: res})
+}
+
+// handlePublisher handles GET /v1/publishers/:id
+func handlePublisher(c fiber.Ctx, db *pgx.Conn) error {
+	publisher, err := HandleLookup(db, c, PublisherById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "PUBLISHERS-02",
+			Title:  "Error retrieving publisher",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: publisher})
+}
+
+// handlePublisherBooks handles GET /v1/publishers/:id/books
+func handlePublisherBooks(c fiber.Ctx, db *pgx.Conn) error {
+	books, err := HandleRelated(db, c, PublisherById, BooksByPublisherId)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "PUBLISHERS-03",
+			Title:  "Error retrieving publisher books",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: books})
+}
+
 // /v1/shipping-methods
 
 // handleAllShippingMethods handles GET /v1/shipping-methods
@@ -258,7 +788,7 @@ func handleAllShippingMethods(c fiber.Ctx, db *pgx.Conn) error {
 	shippingMethods, err := AllShippingMethods(db, c)
 	if err != nil {
 		errorRes := &GravityResponse{Errors: []GravityError{{
-			Status: fmt.Sprint(http.StatusInternalServerError),
+			Status: fmt.Sprint(errorStatus(err)),
 			Code:   "SHIPPING-METHODS-01",
 			Title:  "Error retrieving shipping methods",
 			Detail: err.Error(),
@@ -269,25 +799,67 @@ func handleAllShippingMethods(c fiber.Ctx, db *pgx.Conn) error {
 	return SendGravityResponse(c, &GravityResponse{Data: shippingMethods})
 }
 
+// handleShippingMethodsSearch handles GET /v1/shipping-methods/search?<searchTerm>=<searchValue>
+// Valid query params for search terms are defined within the function
+func handleShippingMethodsSearch(c fiber.Ctx, db *pgx.Conn) error {
+	var validShippingMethodSearchTerms = []string{"name"}
+
+	res, err := HandleSearch(db, c, validShippingMethodSearchTerms, ShippingMethod{}, ShippingMethodsBySearchTerm)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "SHIPPING-METHODS-03",
+			Title:  "Error searching shipping methods",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: res})
+}
+
+// handleShippingMethod handles GET /v1/shipping-methods/:id
+func handleShippingMethod(c fiber.Ctx, db *pgx.Conn) error {
+	shippingMethod, err := HandleLookup(db, c, ShippingMethodById)
+	if err != nil {
+		errorRes := &GravityResponse{Errors: []GravityError{{
+			Status: fmt.Sprint(err.Code),
+			Code:   "SHIPPING-METHODS-02",
+			Title:  "Error retrieving shipping method",
+			Detail: err.Error(),
+		}}}
+		return SendGravityResponse(c, errorRes)
+	}
+
+	return SendGravityResponse(c, &GravityResponse{Data: shippingMethod})
+}
+
 // parseLimitOffset checks for query params 'limit' and 'offset'
-// Sets a c.Locals for future handlers if they are valid, otherwise sets them to defaults
-// Used as LIMIT and OFFSET in subsequent SQL queries
+// Sets a c.Locals for future handlers if they are valid, otherwise sets them to defaults and adds a warning to the response meta
+// Used as LIMIT and OFFSET in subsequent SQL queries by queryPage, and reported back in the pagination meta
 func parseLimitOffset(c fiber.Ctx) error {
-	var limit int
-	var offset int
-
-	requestedLimit, err := strconv.Atoi(c.Query("limit"))
-	if err != nil || c.Query("limit") == "" || requestedLimit > responseSizeLimit {
-		limit = responseSizeLimit
-	} else {
-		limit = requestedLimit
+	limit := responseSizeLimit
+	offset := 0
+
+	if c.Query("limit") != "" {
+		requestedLimit, err := strconv.Atoi(c.Query("limit"))
+		switch {
+		case err != nil || requestedLimit < 0:
+			addWarning(c, "LIMIT-01", fmt.Sprintf("invalid limit '%v', must be a positive integer. limit %d was used", c.Query("limit"), limit))
+		case requestedLimit > responseSizeLimit:
+			addWarning(c, "LIMIT-02", fmt.Sprintf("limit %d is over the maximum of %d. limit %d was used", requestedLimit, responseSizeLimit, limit))
+		default:
+			limit = requestedLimit
+		}
 	}
 
-	requestedOffset, err := strconv.Atoi(c.Query("offset"))
-	if err != nil || c.Query("offset") == "" {
-		offset = 0
-	} else {
-		offset = requestedOffset
+	if c.Query("offset") != "" {
+		requestedOffset, err := strconv.Atoi(c.Query("offset"))
+		if err != nil || requestedOffset < 0 {
+			addWarning(c, "OFFSET-01", fmt.Sprintf("invalid offset '%v', must be a positive integer. offset %d was used", c.Query("offset"), offset))
+		} else {
+			offset = requestedOffset
+		}
 	}
 
 	c.Locals("limit", fmt.Sprintf("%d", limit))
@@ -22,6 +22,25 @@ func TestRouteStatusOK(t *testing.T) {
 		"/v1/customers",
 		"/v1/publishers",
 		"/v1/shipping-methods",
+		"/v1/search?q=christie",
+		"/v1/countries/1",
+		"/v1/authors/1",
+		"/v1/books/1",
+		"/v1/customers/1",
+		"/v1/publishers/1",
+		"/v1/shipping-methods/1",
+		"/v1/orders",
+		"/v1/orders/1",
+		"/v1/orders/1/lines",
+		"/v1/orders/1/history",
+		"/v1/customers/1/addresses",
+		"/v1/languages",
+		"/v1/languages/1",
+		"/v1/languages/1/books",
+		"/v1/authors/1/books",
+		"/v1/publishers/1/books",
+		"/v1/countries/1/customers",
+		"/v1/countries/1/orders",
 	}
 
 	r := initRouter()
@@ -73,12 +92,33 @@ func TestSearchErrors(t *testing.T) {
 		expectedStatusCode int
 		expectedMessage    string
 	}{
-		{search: "/v1/customers/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "multiple search terms not supported"},
+		{search: "/v1/customers/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [email]"},
+		{search: "/v1/customers/search?email=foo&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search term 'bar'. valid search terms: [email]"},
 		{search: "/v1/customers/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [email]"},
-		{search: "/v1/books/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "multiple search terms not supported"},
-		{search: "/v1/books/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [title isbn author]"},
-		{search: "/v1/authors/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "multiple search terms not supported"},
+		{search: "/v1/books/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [title isbn author publishedFrom publishedTo minPages maxPages]"},
+		{search: "/v1/books/search?title=foo&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search term 'bar'. valid search terms: [title isbn author publishedFrom publishedTo minPages maxPages]"},
+		{search: "/v1/books/search?author=Agatha Christie&title=The Tempest", expectedStatusCode: fiber.ErrNotFound.Code, expectedMessage: "no results found"},
+		{search: "/v1/books/search?isbn=9781559277587&match=fulltext", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "match mode 'fulltext' is not supported for search term 'isbn'"},
+		{search: "/v1/books/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [title isbn author publishedFrom publishedTo minPages maxPages]"},
+		{search: "/v1/authors/search?foo=1&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [name]"},
+		{search: "/v1/authors/search?name=foo&bar=2", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search term 'bar'. valid search terms: [name]"},
+		{search: "/v1/authors/search?name=foo&fields=bogus", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search term 'fields'. valid search terms: [name]"},
+		{search: "/v1/authors/search?name=foo&fields[books]=id", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search term 'fields[books]'. valid search terms: [name]"},
+		{search: "/v1/authors/search?name=foo&match=fuzzy", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid match mode 'fuzzy'. valid match modes: [exact insensitive prefix contains fulltext]"},
+		{search: "/v1/authors/search?name=%25&match=contains&limit=1", expectedStatusCode: fiber.ErrNotFound.Code, expectedMessage: "no results found"},
 		{search: "/v1/authors/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [name]"},
+		{search: "/v1/publishers/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [name]"},
+		{search: "/v1/publishers/search?name=foo&match=fulltext", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "match mode 'fulltext' is not supported for search term 'name'"},
+		{search: "/v1/countries/search?name=Atlantis", expectedStatusCode: fiber.ErrNotFound.Code, expectedMessage: "no results found"},
+		{search: "/v1/shipping-methods/search?cost=5", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [name]"},
+		{search: "/v1/orders/search?customerId=one", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid customerId 'one', must be an integer"},
+		{search: "/v1/orders/search?customerId=99999999999", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid customerId '99999999999', must be an integer"},
+		{search: "/v1/orders/search?foo=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no valid search term / value found. valid search terms: [customerId shippingMethodId city country status]"},
+		{search: "/v1/search", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "no search query found, 'q' is required"},
+		{search: "/v1/search?q=christie&types=book,language", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid search type 'language'. valid search types: [book author publisher customer]"},
+		{search: "/v1/search?q=christie&sort=id", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "invalid query param 'sort'. valid query params: [q types match limit offset format]"},
+		{search: "/v1/search?q=christie&types=publisher&match=fulltext", expectedStatusCode: fiber.ErrBadRequest.Code, expectedMessage: "publisher: match mode 'fulltext' is not supported for search term 'name'"},
+		{search: "/v1/search?q=zzzzzzzzzz", expectedStatusCode: fiber.ErrNotFound.Code, expectedMessage: "no results found"},
 	}
 
 	r := initRouter()
@@ -105,6 +145,106 @@ func TestSearchErrors(t *testing.T) {
 	}
 }
 
+func TestLookupErrors(t *testing.T) {
+	var tests = []struct {
+		route              string
+		expectedStatusCode int
+		expectedCode       string
+		expectedMessage    string
+	}{
+		{route: "/v1/books/foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "BOOKS-03", expectedMessage: "invalid id 'foo', id must be an integer"},
+		{route: "/v1/books/99999999999", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "BOOKS-03", expectedMessage: "invalid id '99999999999', id must be an integer"},
+		{route: "/v1/books/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "BOOKS-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/books?include=foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "BOOKS-01", expectedMessage: "invalid include 'foo'. valid includes: [authors publisher language]"},
+		{route: "/v1/books/1?include=foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "BOOKS-03", expectedMessage: "invalid include 'foo'. valid includes: [authors publisher language]"},
+		{route: "/v1/authors/foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "AUTHORS-03", expectedMessage: "invalid id 'foo', id must be an integer"},
+		{route: "/v1/authors/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "AUTHORS-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/authors/999999/books", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "AUTHORS-04", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/authors/79/books?sort=foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "AUTHORS-04", expectedMessage: "invalid sort field 'foo'. valid sort fields: [id isbn languageId numPages publicationDate publisherId title]"},
+		{route: "/v1/authors/79/books?filter[foo]=1", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "AUTHORS-04", expectedMessage: "invalid filter field 'foo'. valid filter fields: [id isbn languageId numPages publicationDate publisherId title]"},
+		{route: "/v1/customers/1.5", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "CUSTOMERS-03", expectedMessage: "invalid id '1.5', id must be an integer"},
+		{route: "/v1/customers/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "CUSTOMERS-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/customers/999999/addresses", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "CUSTOMERS-04", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/languages/foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "LANGUAGES-02", expectedMessage: "invalid id 'foo', id must be an integer"},
+		{route: "/v1/languages/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "LANGUAGES-02", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/languages/999999/books", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "LANGUAGES-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/countries/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "COUNTRIES-02", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/countries/999999/customers", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "COUNTRIES-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/countries/999999/orders", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "COUNTRIES-04", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/publishers/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "PUBLISHERS-02", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/orders/foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "ORDERS-02", expectedMessage: "invalid id 'foo', id must be an integer"},
+		{route: "/v1/orders/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "ORDERS-02", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/orders/999999/lines", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "ORDERS-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/orders/1/lines?filter[price]=cheap", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "ORDERS-03", expectedMessage: "invalid filter value 'cheap' for 'price', must be a number"},
+		{route: "/v1/orders/999999/history", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "ORDERS-04", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/orders/1?include=foo", expectedStatusCode: fiber.ErrBadRequest.Code, expectedCode: "ORDERS-02", expectedMessage: "invalid include 'foo'. valid includes: [lines]"},
+		{route: "/v1/publishers/999999/books", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "PUBLISHERS-03", expectedMessage: "no result found with id 999999"},
+		{route: "/v1/shipping-methods/999999", expectedStatusCode: fiber.ErrNotFound.Code, expectedCode: "SHIPPING-METHODS-02", expectedMessage: "no result found with id 999999"},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var gr GravityResponse
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			json.Unmarshal(body, &gr)
+
+			assert.Equal(t, test.expectedStatusCode, resp.StatusCode)
+			assert.Equal(t, test.expectedCode, gr.Errors[0].Code)
+			assert.Equal(t, test.expectedMessage, gr.Errors[0].Detail)
+		})
+	}
+}
+
+func TestRangeErrors(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedCode    string
+		expectedMessage string
+	}{
+		{route: "/v1/books?publishedFrom=90s", expectedCode: "BOOKS-01", expectedMessage: "invalid publishedFrom '90s', must be a year (YYYY) or date (YYYY-MM-DD)"},
+		{route: "/v1/books?maxPages=-1", expectedCode: "BOOKS-01", expectedMessage: "invalid maxPages '-1', must be a positive integer"},
+		{route: "/v1/books?minPages=99999999999", expectedCode: "BOOKS-01", expectedMessage: "invalid minPages '99999999999', must be a positive integer"},
+		{route: "/v1/books/search?author=Agatha Christie&minPages=many", expectedCode: "BOOKS-02", expectedMessage: "invalid minPages 'many', must be a positive integer"},
+		{route: "/v1/books/search?publishedTo=2000-02-30", expectedCode: "BOOKS-02", expectedMessage: "invalid publishedTo '2000-02-30', must be a year (YYYY) or date (YYYY-MM-DD)"},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var gr GravityResponse
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			json.Unmarshal(body, &gr)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, test.expectedCode, gr.Errors[0].Code)
+			assert.Equal(t, test.expectedMessage, gr.Errors[0].Detail)
+		})
+	}
+}
+
 func TestLimit(t *testing.T) {
 
 	var tests = []struct {
@@ -152,7 +292,7 @@ func TestOffset(t *testing.T) {
 		{route: "/v1/books?offset=150", expectedFirstId: 151},
 		{route: "/v1/books?offset=", expectedFirstId: 1},
 		{route: "/v1/books?offset=0", expectedFirstId: 1},
-		{route: "/v1/books/search?author=Agatha Christie&offset=5", expectedFirstId: 9559},
+		{route: "/v1/books/search?author=Agatha Christie&offset=5", expectedFirstId: 64},
 	}
 
 	r := initRouter()
@@ -186,7 +326,7 @@ func TestCombinedLimitOffset(t *testing.T) {
 	}{
 		{route: "/v1/books?limit=50&offset=5", expectedSize: 50, expectedFirstId: 6},
 		{route: "/v1/books?offset=5&limit=50", expectedSize: 50, expectedFirstId: 6},
-		{route: "/v1/books/search?author=Agatha Christie&limit=10&offset=3", expectedSize: 10, expectedFirstId: 35},
+		{route: "/v1/books/search?author=Agatha Christie&limit=10&offset=3", expectedSize: 10, expectedFirstId: 40},
 	}
 
 	r := initRouter()
@@ -211,3 +351,110 @@ func TestCombinedLimitOffset(t *testing.T) {
 		})
 	}
 }
+
+func TestSort(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedFirstId int
+	}{
+		{route: "/v1/books?sort=-id", expectedFirstId: 11120},
+		{route: "/v1/publishers?sort=-bookCount", expectedFirstId: 2113},
+		{route: "/v1/books/search?author=Agatha Christie&sort=-publicationDate", expectedFirstId: 7585},
+		{route: "/v1/authors/79/books?sort=publicationDate", expectedFirstId: 4996},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedFirstId, a.Get("data[0].id").Int())
+		})
+	}
+}
+
+func TestSortErrors(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedCode    string
+		expectedMessage string
+	}{
+		{route: "/v1/countries?sort=population", expectedCode: "COUNTRIES-01", expectedMessage: "invalid sort field 'population'. valid sort fields: [countryName id]"},
+		{route: "/v1/authors?sort=-name", expectedCode: "AUTHORS-01", expectedMessage: "invalid sort field 'name'. valid sort fields: [authorName id]"},
+		{route: "/v1/customers/search?email=foo&sort=password", expectedCode: "CUSTOMERS-02", expectedMessage: "invalid sort field 'password'. valid sort fields: [email firstName id lastName]"},
+		{route: "/v1/orders/1/history?sort=foo", expectedCode: "ORDERS-04", expectedMessage: "invalid sort field 'foo'. valid sort fields: [id status statusDate statusId]"},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var gr GravityResponse
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			json.Unmarshal(body, &gr)
+
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, test.expectedCode, gr.Errors[0].Code)
+			assert.Equal(t, test.expectedMessage, gr.Errors[0].Detail)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var tests = []struct {
+		route           string
+		expectedFirstId int
+	}{
+		{route: "/v1/books?filter[numPages][gte]=2000", expectedFirstId: 11},
+		{route: "/v1/books?filter[languageId][in]=2&filter[numPages][gt]=300", expectedFirstId: 7},
+		{route: "/v1/shipping-methods?filter[cost][gt]=10", expectedFirstId: 3},
+		{route: "/v1/shipping-methods?filter[cost][in]=8.90,11.90", expectedFirstId: 2},
+		{route: "/v1/orders?filter[customerId]=1&filter[shippingMethodId]=3", expectedFirstId: 2001},
+		{route: "/v1/authors?filter[authorName][prefix]=agatha", expectedFirstId: 79},
+		{route: "/v1/authors/79/books?filter[numPages][gte]=600", expectedFirstId: 48},
+		{route: "/v1/orders/1/lines?filter[price][gt]=5", expectedFirstId: 10570},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedFirstId, a.Get("data[0].id").Int())
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Order struct {
+	Id               int            `json:"id"`
+	OrderDate        time.Time      `json:"orderDate"`
+	CustomerId       int            `json:"customerId"`
+	ShippingMethodId int            `json:"shippingMethodId"`
+	DestAddress      Address        `json:"destAddress"`
+	Subtotal         pgtype.Numeric `json:"subtotal"`        // Sum of the order's line prices, kept as a numeric to avoid float rounding
+	CurrentStatus    *string        `json:"currentStatus"`   // Status of the latest order_history entry, or null if the order has no history
+	Lines            []OrderLine    `json:"lines,omitempty"` // Only populated when requested with ?include=lines
+}
+
+type OrderLine struct {
+	Id     int            `json:"id"`
+	BookId int            `json:"bookId"`
+	Title  string         `json:"title"`
+	Price  pgtype.Numeric `json:"price"`
+}
+
+type OrderHistoryEntry struct {
+	Id         int       `json:"id"`
+	StatusId   int       `json:"statusId"`
+	Status     string    `json:"status"`
+	StatusDate time.Time `json:"statusDate"`
+}
+
+var validOrderIncludes = []string{"lines"}
+
+// validIncludes returns validOrderIncludes, so that the related resources of a Order can be told apart from its other fields, see includer
+func (Order) validIncludes() []string {
+	return validOrderIncludes
+}
+
+// orderSortFields maps the sortable Order fields to their columns
+var orderSortFields = map[string]string{
+	"id":               "cust_order.order_id",
+	"orderDate":        "cust_order.order_date",
+	"customerId":       "cust_order.customer_id",
+	"shippingMethodId": "cust_order.shipping_method_id",
+	"subtotal":         orderSubtotal,
+}
+
+// orderLineSortFields maps the sortable OrderLine fields to their columns
+var orderLineSortFields = map[string]string{
+	"id":     "order_line.line_id",
+	"bookId": "order_line.book_id",
+	"title":  "book.title",
+	"price":  "order_line.price",
+}
+
+// orderHistorySortFields maps the sortable OrderHistoryEntry fields to their columns
+var orderHistorySortFields = map[string]string{
+	"id":         "order_history.history_id",
+	"statusId":   "order_status.status_id",
+	"status":     "order_status.status_value",
+	"statusDate": "order_history.status_date",
+}
+
+// orderLineFilterFields maps the OrderLine fields that can be used in filter[field][operator] query params to their columns
+var orderLineFilterFields = map[string]filterField{
+	"id":     {column: "order_line.line_id", kind: filterInt},
+	"bookId": {column: "order_line.book_id", kind: filterInt},
+	"title":  {column: "book.title", kind: filterText},
+	"price":  {column: "order_line.price", kind: filterNumeric},
+}
+
+// orderHistoryFilterFields maps the OrderHistoryEntry fields that can be used in filter[field][operator] query params to their columns
+var orderHistoryFilterFields = map[string]filterField{
+	"id":         {column: "order_history.history_id", kind: filterInt},
+	"statusId":   {column: "order_status.status_id", kind: filterInt},
+	"status":     {column: "order_status.status_value", kind: filterText},
+	"statusDate": {column: "order_history.status_date::date", kind: filterDate},
+}
+
+// orderSubtotal is the sum of an order's line prices
+// It is a separate expression from orderSelect so that it can also be compared against in cursor conditions, where the alias can't be used
+const orderSubtotal = `(SELECT COALESCE(SUM(order_line.price), 0) FROM order_line WHERE order_line.order_id = cust_order.order_id)`
+
+// orderCurrentStatus is the status of an order's latest order_history entry
+// It is a separate expression from orderSelect so that it can also be searched on
+const orderCurrentStatus = `(SELECT order_status.status_value FROM order_history
+		JOIN order_status ON order_status.status_id = order_history.status_id
+		WHERE order_history.order_id = cust_order.order_id
+		ORDER BY order_history.status_date DESC, order_history.history_id DESC LIMIT 1)`
+
+// orderSelect is shared by the order queries, as an order is assembled from cust_order, its destination address and its lines
+const orderSelect = `SELECT cust_order.order_id, cust_order.order_date, cust_order.customer_id, cust_order.shipping_method_id,
+	address.address_id, address.street_number, address.street_name, address.city, country.country_id, country.country_name,
+	` + orderSubtotal + ` AS subtotal,
+	` + orderCurrentStatus + ` AS current_status
+	FROM cust_order
+	JOIN address ON address.address_id = cust_order.dest_address_id
+	JOIN country ON country.country_id = address.country_id`
+
+// orderLineSelect is shared by the order line queries, joining each line to its book for the title
+const orderLineSelect = `SELECT order_line.line_id, order_line.order_id, order_line.book_id, book.title, order_line.price
+	FROM order_line
+	JOIN book ON book.book_id = order_line.book_id`
+
+// orderFilterFields maps the Order fields that can be used in filter[field][operator] query params to their columns
+var orderFilterFields = map[string]filterField{
+	"id":               {column: "cust_order.order_id", kind: filterInt},
+	"orderDate":        {column: "cust_order.order_date::date", kind: filterDate},
+	"customerId":       {column: "cust_order.customer_id", kind: filterInt},
+	"shippingMethodId": {column: "cust_order.shipping_method_id", kind: filterInt},
+	"city":             {column: "address.city", kind: filterText},
+	"country":          {column: "country.country_name", kind: filterText},
+	"status":           {column: orderCurrentStatus, kind: filterText},
+}
+
+// AllOrders returns all orders from the database as []Order
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
+// Lines are embedded if requested with ?include=lines
+// []Order is returned in all cases, so requires a check for error being nil
+func AllOrders(db *pgx.Conn, c fiber.Ctx) ([]Order, error) {
+	var orders []Order
+	var where whereClause
+	if ferr := parseFilters(c, &where, orderFilterFields); ferr != nil {
+		return orders, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, orderSortFields, "cust_order.order_id")
+	if ferr != nil {
+		return orders, ferr
+	}
+
+	sql := orderSelect + " " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return orders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return orders, err
+		}
+		orders = append(orders, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return orders, err
+	}
+
+	if hasInclude(c, "lines") {
+		err = embedOrderLines(db, orders)
+	}
+	return orders, err
+}
+
+// OrderById returns a single Order from the database with the given id
+// Lines are embedded if requested with ?include=lines
+// pgx.ErrNoRows is returned if no order exists with that id
+func OrderById(db *pgx.Conn, c fiber.Ctx, id int) (Order, error) {
+	o, err := scanOrder(db.QueryRow(context.Background(), orderSelect+" WHERE cust_order.order_id=$1", id))
+	if err != nil {
+		return o, err
+	}
+
+	if hasInclude(c, "lines") {
+		orders := []Order{o}
+		err = embedOrderLines(db, orders)
+		o = orders[0]
+	}
+	return o, err
+}
+
+// OrdersBySearchTerm returns []Order from the database where each searchTerm matches searchValue, combined with AND
+// Text values are compared using the search term's match mode, see addSearchTerm, while id values must be integers and are always matched exactly
+// To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
+// []Order is returned in all cases, so requires a check for error being nil
+func OrdersBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Order, error) {
+	var orders []Order
+	var where whereClause
+	for _, st := range searchTerms {
+		if st.Match == matchFulltext {
+			return orders, unsupportedMatchError(st)
+		}
+
+		switch st.Term {
+		case "customerId":
+			if err := where.addIdSearchTerm("cust_order.customer_id", st); err != nil {
+				return orders, err
+			}
+		case "shippingMethodId":
+			if err := where.addIdSearchTerm("cust_order.shipping_method_id", st); err != nil {
+				return orders, err
+			}
+		case "city":
+			where.addSearchTerm("address.city", st)
+		case "country":
+			where.addSearchTerm("country.country_name", st)
+		case "status":
+			where.addSearchTerm(orderCurrentStatus, st)
+		default:
+			return orders, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, orderFilterFields); ferr != nil {
+		return orders, ferr
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, orderSortFields, "cust_order.order_id")
+	if ferr != nil {
+		return orders, ferr
+	}
+
+	sql := orderSelect + " " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return orders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return orders, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
+
+// OrdersByCountryId returns the orders shipped to an address in the country with the given id as []Order
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given, see orderSortFields
+// []Order is returned in all cases, so requires a check for error being nil
+func OrdersByCountryId(db *pgx.Conn, c fiber.Ctx, id int) ([]Order, error) {
+	var orders []Order
+	var where whereClause
+	where.add("address.country_id = %s", id)
+	if ferr := parseFilters(c, &where, orderFilterFields); ferr != nil {
+		return orders, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, orderSortFields, "cust_order.order_id")
+	if ferr != nil {
+		return orders, ferr
+	}
+	rows, err := queryPage(db, c, orderSelect+" "+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return orders, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return orders, err
+		}
+		orders = append(orders, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return orders, err
+	}
+	return orders, nil
+}
+
+// OrderLinesByOrderId returns the lines of the order with the given id as []OrderLine
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
+// []OrderLine is returned in all cases, so requires a check for error being nil
+func OrderLinesByOrderId(db *pgx.Conn, c fiber.Ctx, id int) ([]OrderLine, error) {
+	var lines []OrderLine
+	var where whereClause
+	where.add("order_line.order_id = %s", id)
+	if ferr := parseFilters(c, &where, orderLineFilterFields); ferr != nil {
+		return lines, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, orderLineSortFields, "order_line.line_id")
+	if ferr != nil {
+		return lines, ferr
+	}
+	rows, err := queryPage(db, c, orderLineSelect+" "+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return lines, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l OrderLine
+		var orderId int
+		err := rows.Scan(&l.Id, &orderId, &l.BookId, &l.Title, &l.Price)
+		if err != nil {
+			return lines, err
+		}
+		lines = append(lines, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return lines, err
+	}
+	return lines, nil
+}
+
+// OrderHistoryByOrderId returns the status timeline of the order with the given id as []OrderHistoryEntry
+// Filtered by any filter[field][operator] query params, see parseFilters. Oldest first, unless sorted by the 'sort' query param
+// []OrderHistoryEntry is returned in all cases, so requires a check for error being nil
+func OrderHistoryByOrderId(db *pgx.Conn, c fiber.Ctx, id int) ([]OrderHistoryEntry, error) {
+	var history []OrderHistoryEntry
+	var where whereClause
+	where.add("order_history.order_id = %s", id)
+	if ferr := parseFilters(c, &where, orderHistoryFilterFields); ferr != nil {
+		return history, ferr
+	}
+
+	keys, ferr := parseSortKeys(c, orderHistorySortFields)
+	if ferr != nil {
+		return history, ferr
+	}
+	// Oldest first by default, which is applied to cursors like a requested sort
+	if len(keys) == 0 {
+		keys = []sortKey{{field: "statusDate", column: "order_history.status_date"}}
+	}
+	orderBy, ferr := applyCursor(c, &where, keys, "order_history.history_id")
+	if ferr != nil {
+		return history, ferr
+	}
+	rows, err := queryPage(db, c,
+		`SELECT order_history.history_id, order_status.status_id, order_status.status_value, order_history.status_date
+		FROM order_history
+		JOIN order_status ON order_status.status_id = order_history.status_id
+		`+where.sql()+" "+orderBy, where.args...)
+	if err != nil {
+		return history, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var h OrderHistoryEntry
+		err := rows.Scan(&h.Id, &h.StatusId, &h.Status, &h.StatusDate)
+		if err != nil {
+			return history, err
+		}
+		history = append(history, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return history, err
+	}
+	return history, nil
+}
+
+// embedOrderLines fetches the lines for all given orders in a single query and attaches them to each order
+func embedOrderLines(db *pgx.Conn, orders []Order) error {
+	var ids []int
+	for _, o := range orders {
+		ids = append(ids, o.Id)
+	}
+
+	rows, err := db.Query(context.Background(), orderLineSelect+" WHERE order_line.order_id = ANY($1) ORDER BY order_line.line_id", ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	linesByOrder := make(map[int][]OrderLine)
+	for rows.Next() {
+		var l OrderLine
+		var orderId int
+		err := rows.Scan(&l.Id, &orderId, &l.BookId, &l.Title, &l.Price)
+		if err != nil {
+			return err
+		}
+		linesByOrder[orderId] = append(linesByOrder[orderId], l)
+	}
+
+	for i := range orders {
+		orders[i].Lines = linesByOrder[orders[i].Id]
+	}
+	return rows.Err()
+}
+
+// scanOrder scans a row selected with orderSelect into an Order
+func scanOrder(row pgx.Row) (Order, error) {
+	var o Order
+	err := row.Scan(&o.Id, &o.OrderDate, &o.CustomerId, &o.ShippingMethodId,
+		&o.DestAddress.Id, &o.DestAddress.StreetNumber, &o.DestAddress.StreetName, &o.DestAddress.City, &o.DestAddress.CountryId, &o.DestAddress.CountryName,
+		&o.Subtotal, &o.CurrentStatus)
+	return o, err
+}
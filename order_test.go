@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderById(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/orders/1", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 1, a.Get("data.id").Int())
+	assert.Equal(t, 1, a.Get("data.customerId").Int())
+	assert.Equal(t, 1, a.Get("data.shippingMethodId").Int())
+	assert.Equal(t, 299, a.Get("data.destAddress.id").Int())
+	assert.Equal(t, 13.11, a.Get("data.subtotal").Float64())
+	assert.Nil(t, a.Get("data.lines").Data())
+	assert.Equal(t, "Pending Delivery", a.Get("data.currentStatus").Str())
+}
+
+func TestOrderHistory(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1/orders/1/history", nil)
+	resp, err := initRouter().Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Len(t, a.Get("data").InterSlice(), 2)
+	assert.Equal(t, "Order Received", a.Get("data[0].status").Str())
+	assert.Equal(t, "Pending Delivery", a.Get("data[1].status").Str())
+}
+
+func TestOrderLines(t *testing.T) {
+	var tests = []struct {
+		route         string
+		linesPath     string
+		expectedSize  int
+		expectedTitle string
+	}{
+		{route: "/v1/orders/1/lines", linesPath: "data", expectedSize: 4, expectedTitle: "Cursor's Fury (Codex Alera  #3)"},
+		{route: "/v1/orders/1?include=lines", linesPath: "data.lines", expectedSize: 4, expectedTitle: "Cursor's Fury (Codex Alera  #3)"},
+		{route: "/v1/orders?include=lines&limit=1", linesPath: "data[0].lines", expectedSize: 4, expectedTitle: "Cursor's Fury (Codex Alera  #3)"},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Len(t, a.Get(test.linesPath).InterSlice(), test.expectedSize)
+			assert.Equal(t, test.expectedTitle, a.Get(test.linesPath+"[0].title").Str())
+			assert.Equal(t, 3.85, a.Get(test.linesPath+"[0].price").Float64())
+		})
+	}
+}
+
+func TestAllOrdersError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := AllOrders(db, c)
+
+	assert.Equal(t, []Order(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrderByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := OrderById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrderLinesByOrderIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := OrderLinesByOrderId(db, c, 1)
+
+	assert.Equal(t, []OrderLine(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrderHistoryByOrderIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := OrderHistoryByOrderId(db, c, 1)
+
+	assert.Equal(t, []OrderHistoryEntry(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrdersByCountryIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := OrdersByCountryId(db, c, 149)
+
+	assert.Equal(t, []Order(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrderSearchError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := OrdersBySearchTerm(db, c, []SearchTerm{{Term: "city", Value: "Oguma"}})
+
+	assert.Equal(t, []Order(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestOrderSearchInvalidTerm(t *testing.T) {
+	var db *pgx.Conn
+	var c fiber.Ctx
+
+	res, err := OrdersBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
+
+	assert.Equal(t, []Order(nil), res)
+	assert.Equal(t, errors.New("invalid search term"), err)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+)
+
+// Pagination is added to the meta of every paged response, so that clients can tell how many results there are in total
+// Limit and Offset are the values actually used, after parseLimitOffset has applied its defaults and responseSizeLimit.
+// Pages fetched with a cursor are not counted, so Total and Offset are omitted for them
+type Pagination struct {
+	Total      *int            `json:"total,omitempty"`
+	Limit      int             `json:"limit"`
+	Offset     *int            `json:"offset,omitempty"`
+	NextCursor string          `json:"nextCursor,omitempty"` // Cursor for the next page, if the endpoint supports cursors and there may be more results
+	Links      PaginationLinks `json:"links"`
+}
+
+// PaginationLinks are the URLs of the surrounding pages. Prev and Next are omitted on the first and last pages,
+// and Prev and Last are omitted for pages fetched with a cursor
+type PaginationLinks struct {
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// paginated reports whether the request's pagination is stored in c.Locals.
+// The unified search turns this off for each of its searches, as each type is paged separately so there is no single pagination for the response
+func paginated(c fiber.Ctx) bool {
+	paginate, ok := c.Locals("paginate").(bool)
+	return !ok || paginate
+}
+
+// queryPage runs sql with the limit and offset from parseLimitOffset appended, after counting all of the rows it would return without them.
+// The count is stored in c.Locals("total"), which SendGravityResponse uses to add Pagination to the meta and a Link header.
+// Pages after a cursor are not counted, as they are meant for tables too large to count or offset into, see applyCursor.
+// One row more than the limit is fetched, so that pageRows can tell whether there is a next page to return a cursor for.
+// sql must not have its own LIMIT or OFFSET, and args are its positional args
+func queryPage(db *pgx.Conn, c fiber.Ctx, sql string, args ...any) (pgx.Rows, error) {
+	paged := fmt.Sprintf("%s LIMIT $%d OFFSET $%d", sql, len(args)+1, len(args)+2)
+	if !paginated(c) {
+		return db.Query(context.Background(), paged, append(append([]any{}, args...), c.Locals("limit"), c.Locals("offset"))...)
+	}
+
+	if _, cursor := c.Locals("cursor").(bool); !cursor {
+		var total int
+		err := db.QueryRow(context.Background(), "SELECT COUNT(*) FROM ("+sql+") AS page_total", args...).Scan(&total)
+		if err != nil {
+			return nil, err
+		}
+		c.Locals("total", total)
+	}
+
+	limit, _ := strconv.Atoi(fmt.Sprint(c.Locals("limit")))
+	rows, err := db.Query(context.Background(), paged, append(append([]any{}, args...), limit+1, c.Locals("offset"))...)
+	if err != nil {
+		return nil, err
+	}
+	return &pageRows{Rows: rows, c: c, remaining: limit}, nil
+}
+
+// pageRows are the rows of a page fetched by queryPage, which stop after the limit rather than returning the extra row.
+// Whether the extra row exists is stored in c.Locals("morePages"), for nextCursor
+type pageRows struct {
+	pgx.Rows
+	c         fiber.Ctx
+	remaining int
+}
+
+func (r *pageRows) Next() bool {
+	if r.remaining <= 0 {
+		if r.Rows.Next() {
+			r.c.Locals("morePages", true)
+		}
+		return false
+	}
+	r.remaining--
+	return r.Rows.Next()
+}
+
+// pagination returns the Pagination for the current request if a paged query was run by queryPage, otherwise nil
+// results are the page's results, used for the next cursor
+func pagination(c fiber.Ctx, results interface{}) *Pagination {
+	limit, _ := strconv.Atoi(fmt.Sprint(c.Locals("limit")))
+	offset, _ := strconv.Atoi(fmt.Sprint(c.Locals("offset")))
+
+	if _, ok := c.Locals("cursor").(bool); ok {
+		p := &Pagination{Limit: limit, NextCursor: nextCursor(c, results)}
+		p.Links.First = pageURL(c, map[string]string{"limit": strconv.Itoa(limit), "cursor": ""})
+		if p.NextCursor != "" {
+			p.Links.Next = pageURL(c, map[string]string{"limit": strconv.Itoa(limit), "cursor": p.NextCursor})
+		}
+		return p
+	}
+
+	total, ok := c.Locals("total").(int)
+	if !ok {
+		return nil
+	}
+
+	p := &Pagination{Total: &total, Limit: limit, Offset: &offset}
+	p.Links.First = offsetPageURL(c, limit, 0)
+	p.Links.Last = p.Links.First
+	if limit <= 0 {
+		return p
+	}
+
+	if total > 0 {
+		p.Links.Last = offsetPageURL(c, limit, (total-1)/limit*limit)
+	}
+	if offset > 0 {
+		p.Links.Prev = offsetPageURL(c, limit, max(offset-limit, 0))
+	}
+	if offset+limit < total {
+		p.Links.Next = offsetPageURL(c, limit, offset+limit)
+		p.NextCursor = nextCursor(c, results)
+	}
+	return p
+}
+
+// offsetPageURL returns the URL of the current request with its limit and offset query params replaced
+func offsetPageURL(c fiber.Ctx, limit int, offset int) string {
+	return pageURL(c, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(offset)})
+}
+
+// pageURL returns the URL of the current request with the given query params replaced, or removed if their value is empty
+func pageURL(c fiber.Ctx, params map[string]string) string {
+	query := url.Values{}
+	for k, v := range c.Queries() {
+		query.Set(k, v)
+	}
+	for k, v := range params {
+		if v == "" {
+			query.Del(k)
+		} else {
+			query.Set(k, v)
+		}
+	}
+	return c.BaseURL() + c.Path() + "?" + query.Encode()
+}
+
+// linkHeader formats the links as an RFC 8288 Link header
+func (l PaginationLinks) linkHeader() string {
+	var links []string
+	for _, link := range []struct{ rel, url string }{{"first", l.First}, {"prev", l.Prev}, {"next", l.Next}, {"last", l.Last}} {
+		if link.url != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.url, link.rel))
+		}
+	}
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagination(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		total    int
+		expected Pagination
+	}{
+		{name: "first page", query: "limit=10&offset=0", total: 25, expected: Pagination{Total: intPtr(25), Limit: 10, Offset: intPtr(0), Links: PaginationLinks{
+			First: "http://example.com/v1/books?limit=10&offset=0",
+			Next:  "http://example.com/v1/books?limit=10&offset=10",
+			Last:  "http://example.com/v1/books?limit=10&offset=20",
+		}}},
+		{name: "middle page", query: "limit=10&offset=5&sort=title", total: 25, expected: Pagination{Total: intPtr(25), Limit: 10, Offset: intPtr(5), Links: PaginationLinks{
+			First: "http://example.com/v1/books?limit=10&offset=0&sort=title",
+			Prev:  "http://example.com/v1/books?limit=10&offset=0&sort=title",
+			Next:  "http://example.com/v1/books?limit=10&offset=15&sort=title",
+			Last:  "http://example.com/v1/books?limit=10&offset=20&sort=title",
+		}}},
+		{name: "last page", query: "limit=10&offset=20", total: 25, expected: Pagination{Total: intPtr(25), Limit: 10, Offset: intPtr(20), Links: PaginationLinks{
+			First: "http://example.com/v1/books?limit=10&offset=0",
+			Prev:  "http://example.com/v1/books?limit=10&offset=10",
+			Last:  "http://example.com/v1/books?limit=10&offset=20",
+		}}},
+		{name: "no results", query: "", total: 0, expected: Pagination{Total: intPtr(0), Limit: 100, Offset: intPtr(0), Links: PaginationLinks{
+			First: "http://example.com/v1/books?limit=100&offset=0",
+			Last:  "http://example.com/v1/books?limit=100&offset=0",
+		}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(parseLimitOffset)
+			app.Get("/v1/books", func(c fiber.Ctx) error {
+				c.Locals("total", test.total)
+				return c.JSON(pagination(c, nil))
+			})
+
+			req, _ := http.NewRequest("GET", "http://example.com/v1/books?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var p Pagination
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, p)
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestPaginationNotPaged(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		assert.Nil(t, pagination(c, nil))
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	_, err := app.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// fakeRows is a pgx.Rows with n rows, where only Next is implemented
+type fakeRows struct {
+	pgx.Rows
+	n int
+}
+
+func (r *fakeRows) Next() bool {
+	r.n--
+	return r.n >= 0
+}
+
+func TestPageRows(t *testing.T) {
+	var tests = []struct {
+		name         string
+		rows         int
+		limit        int
+		expectedRows int
+		expectedMore bool
+	}{
+		{name: "more pages", rows: 3, limit: 2, expectedRows: 2, expectedMore: true},
+		{name: "exactly full last page", rows: 2, limit: 2, expectedRows: 2, expectedMore: false},
+		{name: "partial last page", rows: 1, limit: 2, expectedRows: 1, expectedMore: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c fiber.Ctx) error {
+				rows := &pageRows{Rows: &fakeRows{n: test.rows}, c: c, remaining: test.limit}
+				count := 0
+				for rows.Next() {
+					count++
+				}
+				more, _ := c.Locals("morePages").(bool)
+
+				assert.Equal(t, test.expectedRows, count)
+				assert.Equal(t, test.expectedMore, more)
+				return nil
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			if _, err := app.Test(req); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLinkHeader(t *testing.T) {
+	links := PaginationLinks{First: "/a?offset=0", Next: "/a?offset=10", Last: "/a?offset=20"}
+	assert.Equal(t, `</a?offset=0>; rel="first", </a?offset=10>; rel="next", </a?offset=20>; rel="last"`, links.linkHeader())
+}
+
+func TestPaginationMeta(t *testing.T) {
+	r := initRouter()
+	req, _ := http.NewRequest("GET", "/v1/books?limit=10&offset=20", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 11127, a.Get("meta.pagination.total").Int())
+	assert.Equal(t, 10, a.Get("meta.pagination.limit").Int())
+	assert.Equal(t, 20, a.Get("meta.pagination.offset").Int())
+	assert.Contains(t, a.Get("meta.pagination.links.next").Str(), "/v1/books?limit=10&offset=30")
+	assert.Contains(t, a.Get("meta.pagination.links.last").Str(), "/v1/books?limit=10&offset=11120")
+	assert.Contains(t, resp.Header.Get("Link"), `rel="next"`)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
@@ -10,13 +11,45 @@ import (
 type Publisher struct {
 	Id            int    `json:"id"`
 	PublisherName string `json:"publisherName"`
+	BookCount     *int   `json:"bookCount,omitempty"` // Number of books by the publisher. Not set when embedded in a Book
 }
 
-// AllPublishers returns all publishers from the database as []Publisher
+// publisherBookCount is the number of books by a publisher
+// It is used as an expression rather than by its alias, so that it can also be compared against in cursor conditions
+const publisherBookCount = `(SELECT COUNT(*) FROM book WHERE book.publisher_id = publisher.publisher_id)`
+
+// publisherSortFields maps the sortable Publisher fields to their columns
+var publisherSortFields = map[string]string{
+	"id":            "publisher.publisher_id",
+	"publisherName": "publisher.publisher_name",
+	"bookCount":     publisherBookCount,
+}
+
+// publisherFilterFields maps the Publisher fields that can be used in filter[field][operator] query params to their columns
+var publisherFilterFields = map[string]filterField{
+	"id":            {column: "publisher.publisher_id", kind: filterInt},
+	"publisherName": {column: "publisher.publisher_name", kind: filterText},
+}
+
+// AllPublishers returns all publishers from the database as []Publisher, with their book counts
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
 // []Publisher is returned in all cases, so requires a check for error being nil
 func AllPublishers(db *pgx.Conn, c fiber.Ctx) ([]Publisher, error) {
 	var publishers []Publisher
-	rows, err := db.Query(context.Background(), "SELECT * FROM publisher LIMIT $1 OFFSET $2", c.Locals("limit"), c.Locals("offset"))
+	var where whereClause
+	if ferr := parseFilters(c, &where, publisherFilterFields); ferr != nil {
+		return publishers, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, publisherSortFields, "publisher.publisher_id")
+	if ferr != nil {
+		return publishers, ferr
+	}
+
+	sql := `SELECT publisher.publisher_id, publisher.publisher_name,
+		` + publisherBookCount + ` AS book_count
+		FROM publisher ` + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return publishers, err
 	}
@@ -24,7 +57,7 @@ func AllPublishers(db *pgx.Conn, c fiber.Ctx) ([]Publisher, error) {
 
 	for rows.Next() {
 		var p Publisher
-		err := rows.Scan(&p.Id, &p.PublisherName)
+		err := rows.Scan(&p.Id, &p.PublisherName, &p.BookCount)
 		if err != nil {
 			return publishers, err
 		}
@@ -36,3 +69,62 @@ func AllPublishers(db *pgx.Conn, c fiber.Ctx) ([]Publisher, error) {
 	}
 	return publishers, nil
 }
+
+// PublisherById returns a single Publisher from the database with the given id, with its book count
+// pgx.ErrNoRows is returned if no publisher exists with that id
+func PublisherById(db *pgx.Conn, c fiber.Ctx, id int) (Publisher, error) {
+	var p Publisher
+	err := db.QueryRow(context.Background(),
+		`SELECT publisher.publisher_id, publisher.publisher_name,
+		`+publisherBookCount+` AS book_count
+		FROM publisher WHERE publisher.publisher_id=$1`, id).Scan(&p.Id, &p.PublisherName, &p.BookCount)
+	return p, err
+}
+
+// PublishersBySearchTerm returns []Publisher from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
+// To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
+// []Publisher is returned in all cases, so requires a check for error being nil
+func PublishersBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]Publisher, error) {
+	var publishers []Publisher
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "name":
+			if st.Match == matchFulltext {
+				return publishers, unsupportedMatchError(st)
+			}
+			where.addSearchTerm("publisher.publisher_name", st)
+		default:
+			return publishers, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, publisherFilterFields); ferr != nil {
+		return publishers, ferr
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, publisherSortFields, "publisher.publisher_id")
+	if ferr != nil {
+		return publishers, ferr
+	}
+
+	sql := `SELECT publisher.publisher_id, publisher.publisher_name,
+		` + publisherBookCount + ` AS book_count
+		FROM publisher ` + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return publishers, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Publisher
+		err := rows.Scan(&p.Id, &p.PublisherName, &p.BookCount)
+		if err != nil {
+			return publishers, err
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers, nil
+}
@@ -2,11 +2,50 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net/http"
 	"testing"
 
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestPublisherBooks(t *testing.T) {
+	var tests = []struct {
+		route         string
+		path          string
+		expectedValue int
+	}{
+		{route: "/v1/publishers?limit=1", path: "data[0].bookCount", expectedValue: 2},
+		{route: "/v1/publishers/1010", path: "data.bookCount", expectedValue: 9},
+		{route: "/v1/publishers/1010/books", path: "data[0].publisherId", expectedValue: 1010},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.route, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.route, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedValue, a.Get(test.path).Int())
+		})
+	}
+}
+
 func TestAllPublishersError(t *testing.T) {
 	db := connectToDb()
 	c := initRouter().AcquireCtx()
@@ -17,3 +56,34 @@ func TestAllPublishersError(t *testing.T) {
 	assert.Equal(t, []Publisher(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
 }
+
+func TestPublisherByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := PublisherById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestPublisherSearchError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := PublishersBySearchTerm(db, c, []SearchTerm{{Term: "name", Value: "Vintage"}})
+
+	assert.Equal(t, []Publisher(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestPublisherSearchInvalidTerm(t *testing.T) {
+	var db *pgx.Conn
+	var c fiber.Ctx
+
+	res, err := PublishersBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
+
+	assert.Equal(t, []Publisher(nil), res)
+	assert.Equal(t, errors.New("invalid search term"), err)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereClause collects parameterised conditions to be combined with AND, along with their positional args.
+// User input only ever goes into args, so conditions must be built from whitelisted columns only
+type whereClause struct {
+	conditions []string
+	args       []any
+	ranks      []string // Relevance expressions for full-text search terms, see addSearchTerm
+}
+
+// add appends a condition, replacing each %s in it with the positional placeholder of the matching arg
+func (w *whereClause) add(condition string, args ...any) {
+	placeholders := make([]any, len(args))
+	for i, arg := range args {
+		placeholders[i] = w.arg(arg)
+	}
+	w.conditions = append(w.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+// arg appends a single arg and returns its positional placeholder, e.g. for use with LIMIT and OFFSET
+func (w *whereClause) arg(arg any) string {
+	w.args = append(w.args, arg)
+	return fmt.Sprintf("$%d", len(w.args))
+}
+
+// sql returns the conditions as a WHERE clause, or an empty string if there are none
+func (w *whereClause) sql() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// rank returns the sum of the relevance expressions added by full-text search terms, or NULL if there are none
+func (w *whereClause) rank() string {
+	if len(w.ranks) == 0 {
+		return "NULL::real"
+	}
+	return "(" + strings.Join(w.ranks, " + ") + ")"
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -9,9 +12,24 @@ import (
 )
 
 type GravityResponse struct {
-	Data   interface{}       `json:"data"`
-	Meta   map[string]string `json:"meta"`
-	Errors []GravityError    `json:"errors"`
+	Data   interface{}    `json:"data"`
+	Meta   GravityMeta    `json:"meta"`
+	Errors []GravityError `json:"errors"`
+}
+
+// GravityMeta is information about the response as a whole, rather than the data in it.
+// Timestamp is always set, while the other fields are omitted unless they apply to the response. New kinds of meta are added as fields here
+type GravityMeta struct {
+	Timestamp  string           `json:"timestamp"`            // When the response was sent, in RFC 3339 format. Set automatically
+	Pagination *Pagination      `json:"pagination,omitempty"` // Set automatically for paged responses, see queryPage
+	Facets     map[string]Facet `json:"facets,omitempty"`     // Set by handlers when requested with ?facets=
+	Warnings   []GravityWarning `json:"warnings,omitempty"`   // Non-fatal problems with the request, see addWarning. Set automatically
+}
+
+// GravityWarning is a problem with the request that was worked around rather than failing it, such as an excessive limit being clamped
+type GravityWarning struct {
+	Code   string `json:"code"`   // An application specific warning code
+	Detail string `json:"detail"` // An explanation specific to this occurrence of the problem
 }
 
 type GravityError struct {
@@ -20,16 +38,28 @@ type GravityError struct {
 	Code   string `json:"code"`   // An application specific error code
 	Title  string `json:"title"`  // A short summary of the problem that is the same for each occurrence of the problem
 	Detail string `json:"detail"` // A longer explanation specific to this occurrence of the problem
+
+	Meta map[string]interface{} `json:"meta,omitempty"` // Additional information about the problem, such as search suggestions. Omitted if empty
 }
 
 func (ge *GravityError) Error() string {
 	return ge.Detail
 }
 
+// errorStatus returns the status of err if it is a *fiber.Error, such as a 400 for an invalid query param
+// Any other error came from retrieving the data itself, so is treated as a 500
+func errorStatus(err error) int {
+	var ferr *fiber.Error
+	if errors.As(err, &ferr) {
+		return ferr.Code
+	}
+	return http.StatusInternalServerError
+}
+
 func SendGravityResponse(c fiber.Ctx, gr *GravityResponse) error {
 	httpStatus := fiber.StatusOK
-	gr.Meta = make(map[string]string)
-	gr.Meta["timestamp"] = time.Now().Format(time.RFC3339)
+	gr.Meta.Timestamp = time.Now().Format(time.RFC3339)
+	gr.Meta.Warnings, _ = c.Locals("warnings").([]GravityWarning)
 
 	// ensures that the response is an empty array if there is no data
 	if gr.Data == nil {
@@ -37,7 +67,39 @@ func SendGravityResponse(c fiber.Ctx, gr *GravityResponse) error {
 	}
 
 	if len(gr.Errors) == 0 {
+		// Only the endpoints whose handler called parseFields support ?fields=, so it is rejected elsewhere rather than ignored
+		if param, ok := unsupportedFieldsParam(c); ok {
+			errorRes := &GravityResponse{Errors: []GravityError{{
+				Status: fmt.Sprint(fiber.StatusBadRequest),
+				Code:   "FIELDS-01",
+				Title:  "Invalid query param",
+				Detail: fmt.Sprintf("'%v' is not supported on this endpoint. fields are supported on the book endpoints", param),
+			}}}
+			return SendGravityResponse(c, errorRes)
+		}
+		// Likewise for ?facets= and parseFacets
+		if _, ok := c.Queries()["facets"]; ok && !facetsParsed(c) {
+			errorRes := &GravityResponse{Errors: []GravityError{{
+				Status: fmt.Sprint(fiber.StatusBadRequest),
+				Code:   "FACETS-01",
+				Title:  "Invalid query param",
+				Detail: "'facets' is not supported on this endpoint. facets are supported on the book list and search endpoints",
+			}}}
+			return SendGravityResponse(c, errorRes)
+		}
+
 		gr.Errors = []GravityError{}
+		// Paged responses also get their pagination, as both meta and a Link header
+		if p := pagination(c, gr.Data); p != nil {
+			gr.Meta.Pagination = p
+			c.Set(fiber.HeaderLink, p.Links.linkHeader())
+		}
+		// CSV applies the requested fields itself, as its columns are taken from the type of the data
+		if wantsCSV(c) {
+			return sendCSV(c, httpStatus, gr.Data)
+		}
+		// Trimmed after the pagination, as the next cursor is taken from fields that may not have been requested
+		gr.Data = sparseData(c, gr.Data)
 	} else {
 		statusInt, err := strconv.Atoi(gr.Errors[0].Status) // We set the overall http status response to that of the first GravityError
 		if err == nil {
@@ -50,3 +112,9 @@ func SendGravityResponse(c fiber.Ctx, gr *GravityResponse) error {
 
 	return c.Status(httpStatus).JSON(gr)
 }
+
+// addWarning adds a GravityWarning to the meta of the response to the current request
+func addWarning(c fiber.Ctx, code string, detail string) {
+	warnings, _ := c.Locals("warnings").([]GravityWarning)
+	c.Locals("warnings", append(warnings, GravityWarning{Code: code, Detail: detail}))
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
 
+	"github.com/gofiber/fiber/v3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,3 +14,51 @@ func TestError(t *testing.T) {
 	res := e.Error()
 	assert.Equal(t, "foo", res)
 }
+
+func TestResponseMeta(t *testing.T) {
+	var tests = []struct {
+		query            string
+		expectedWarnings []GravityWarning
+	}{
+		{query: "", expectedWarnings: nil},
+		{query: "limit=10&offset=20", expectedWarnings: nil},
+		{query: "limit=500", expectedWarnings: []GravityWarning{{Code: "LIMIT-02", Detail: "limit 500 is over the maximum of 100. limit 100 was used"}}},
+		{query: "limit=ten&offset=-1", expectedWarnings: []GravityWarning{
+			{Code: "LIMIT-01", Detail: "invalid limit 'ten', must be a positive integer. limit 100 was used"},
+			{Code: "OFFSET-01", Detail: "invalid offset '-1', must be a positive integer. offset 0 was used"},
+		}},
+	}
+
+	app := fiber.New()
+	app.Use(parseLimitOffset)
+	app.Get("/", func(c fiber.Ctx) error {
+		return SendGravityResponse(c, &GravityResponse{Data: []int{1}})
+	})
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+test.query, nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var gr GravityResponse
+			if err := json.NewDecoder(resp.Body).Decode(&gr); err != nil {
+				t.Error(err)
+			}
+
+			assert.NotEmpty(t, gr.Meta.Timestamp)
+			assert.Nil(t, gr.Meta.Pagination)
+			assert.Equal(t, test.expectedWarnings, gr.Meta.Warnings)
+		})
+	}
+}
+
+func TestResponseMetaShape(t *testing.T) {
+	b, err := json.Marshal(GravityMeta{Timestamp: "2024-01-01T00:00:00Z"})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, `{"timestamp":"2024-01-01T00:00:00Z"}`, string(b))
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
@@ -9,39 +15,197 @@ import (
 
 // Defines the possible models we allow to be searchable
 type Searchable interface {
-	Author | Book | Customer
+	Author | Book | Customer | Publisher | Country | ShippingMethod | Order
 }
 
+// SearchTerm is a single whitelisted search term and the value it was given in the query params
+type SearchTerm struct {
+	Term  string
+	Value string
+	Match string // One of validMatchModes, taken from the 'match' query param
+}
+
+// Match modes for the 'match' query param, which apply to every search term in the request
+// prefix and contains are case-insensitive, as they are intended for partial user input
+// fulltext uses Postgres text search, and is only supported by search terms over free text such as book titles and author names
+const (
+	matchExact       = "exact"
+	matchInsensitive = "insensitive"
+	matchPrefix      = "prefix"
+	matchContains    = "contains"
+	matchFulltext    = "fulltext"
+)
+
+var validMatchModes = []string{matchExact, matchInsensitive, matchPrefix, matchContains, matchFulltext}
+
+// searchReservedParams are query params handled elsewhere, so are never treated as search terms
+// filter[field][operator] params are also allowed, and are handled by each search function with parseFilters.
+// fields and fields[type] params are only allowed on endpoints whose handler has called parseFields, see fieldsParsed, and facets on those that have called parseFacets
+var searchReservedParams = []string{"limit", "offset", "cursor", "match", "sort", "format"}
+
 // HandleSearch gives us a more generic way to perform searches without defining a handler for each model.
 // It takes validSearchTerms, a Searchable model and a search function, then returns the resulting []s Searchable
-func HandleSearch[s Searchable](db *pgx.Conn, c fiber.Ctx, validSearchTerms []string, searchModel s, searchFunc func(db *pgx.Conn, c fiber.Ctx, searchTerm, searchValue string) ([]s, error)) ([]s, *fiber.Error) {
+// Multiple search terms are passed to the search function together, to be combined with AND
+func HandleSearch[s Searchable](db *pgx.Conn, c fiber.Ctx, validSearchTerms []string, searchModel s, searchFunc func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]s, error)) ([]s, *fiber.Error) {
 	var results []s
 
-	// Determine how many search terms were given, excluding length and offset params
-	var numSearchTerms int
+	match := c.Query("match", matchExact)
+	if !slices.Contains(validMatchModes, match) {
+		return results, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid match mode '%v'. valid match modes: %v", match, validMatchModes))
+	}
+
+	searchTerms := searchTermsFromQuery(c, validSearchTerms)
+	if len(searchTerms) == 0 {
+		return results, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("no valid search term / value found. valid search terms: %v", validSearchTerms))
+	}
+
+	// Reject unknown params rather than ignoring them, as a mistyped term would otherwise silently widen the search
+	var queryParams []string
 	for k := range c.Queries() {
-		if k != "limit" && k != "offset" {
-			numSearchTerms++
+		queryParams = append(queryParams, k)
+	}
+	sort.Strings(queryParams)
+	for _, k := range queryParams {
+		if !slices.Contains(validSearchTerms, k) && !slices.Contains(searchReservedParams, k) && !strings.HasPrefix(k, "filter[") && !(fieldsParamPattern.MatchString(k) && fieldsParsed(c)) && !(k == "facets" && facetsParsed(c)) {
+			return results, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid search term '%v'. valid search terms: %v", k, validSearchTerms))
 		}
 	}
 
-	if numSearchTerms > 1 {
-		return results, fiber.NewError(fiber.ErrBadRequest.Code, "multiple search terms not supported")
+	results, err := searchFunc(db, c, searchTerms)
+	if len(results) == 0 && err == nil {
+		return results, fiber.NewError(fiber.ErrNotFound.Code, "no results found")
+	}
+	// Search functions return a *fiber.Error for problems with the request itself, such as an unsupported match mode
+	var ferr *fiber.Error
+	if errors.As(err, &ferr) {
+		return results, ferr
 	}
+	if err != nil {
+		return results, fiber.NewError(fiber.ErrInternalServerError.Code, fmt.Sprintf("error retrieving by search %v", err.Error()))
+	}
+	return results, nil
+}
 
+// searchTermsFromQuery returns a SearchTerm for each of validSearchTerms given in the query params, using the 'match' query param
+// It is also used by handlers that need the same search terms as HandleSearch, such as to count facets
+func searchTermsFromQuery(c fiber.Ctx, validSearchTerms []string) []SearchTerm {
+	var searchTerms []SearchTerm
 	for _, searchTerm := range validSearchTerms {
 		if c.Query(searchTerm) != "" {
-			results, err := searchFunc(db, c, searchTerm, c.Query(searchTerm))
-			if len(results) == 0 && err == nil {
-				return results, fiber.NewError(fiber.ErrNotFound.Code, "no results found")
-			}
-			if err != nil {
-				return results, fiber.NewError(fiber.ErrInternalServerError.Code, fmt.Sprintf("error retrieving by search %v", err.Error()))
-			}
-			return results, nil
+			searchTerms = append(searchTerms, SearchTerm{Term: searchTerm, Value: c.Query(searchTerm), Match: c.Query("match", matchExact)})
 		}
 	}
+	return searchTerms
+}
+
+// addSearchTerm adds a condition comparing column to the search term's value using its match mode
+// Wildcards in the value are escaped for the LIKE based modes, so user input is always matched literally
+// Full-text search terms also add their relevance to the rank, to be selected and ordered by
+func (w *whereClause) addSearchTerm(column string, st SearchTerm) {
+	switch st.Match {
+	case matchFulltext:
+		query := w.arg(st.Value)
+		w.conditions = append(w.conditions, fmt.Sprintf("to_tsvector('english', %s) @@ plainto_tsquery('english', %s)", column, query))
+		w.ranks = append(w.ranks, fmt.Sprintf("ts_rank(to_tsvector('english', %s), plainto_tsquery('english', %s))", column, query))
+	case matchInsensitive:
+		w.add("lower("+column+")=lower(%s)", st.Value)
+	case matchPrefix:
+		w.add(column+` ILIKE %s ESCAPE '\'`, escapeLike(st.Value)+"%")
+	case matchContains:
+		w.add(column+` ILIKE %s ESCAPE '\'`, "%"+escapeLike(st.Value)+"%")
+	default:
+		w.add(column+"=%s", st.Value)
+	}
+}
+
+// addRelatedSearchTerm adds a search term on a column of related rows, such as a book's authors, as an EXISTS subquery over from.
+// correlation joins from to the outer row, so that each outer row is returned once however many of its related rows match.
+// Full-text search terms rank each outer row by its best matching related row
+func (w *whereClause) addRelatedSearchTerm(from string, correlation string, column string, st SearchTerm) {
+	related := whereClause{args: w.args}
+	related.addSearchTerm(column, st)
+	w.args = related.args
 
-	return results, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("no valid search term / value found. valid search terms: %v", validSearchTerms))
+	matches := correlation + " AND " + strings.Join(related.conditions, " AND ")
+	w.conditions = append(w.conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM %s WHERE %s)", from, matches))
+	for _, rank := range related.ranks {
+		w.ranks = append(w.ranks, fmt.Sprintf("(SELECT MAX(%s) FROM %s WHERE %s)", rank, from, matches))
+	}
+}
 
+// addIdSearchTerm adds a condition comparing an id column to the search term's value, which must be an integer
+// The match mode does not apply to ids, so they are always matched exactly. A *fiber.Error with a 400 status is returned for a non integer value
+func (w *whereClause) addIdSearchTerm(column string, st SearchTerm) *fiber.Error {
+	id, err := parseInt32(st.Value)
+	if err != nil {
+		return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid %v '%v', must be an integer", st.Term, st.Value))
+	}
+	w.add(column+"=%s", id)
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcard characters % and _, as well as the escape character itself
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// unsupportedMatchError is returned by search functions when a search term does not support the requested match mode
+func unsupportedMatchError(st SearchTerm) *fiber.Error {
+	return fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("match mode '%v' is not supported for search term '%v'", st.Match, st.Term))
+}
+
+// searchOrderBy orders full-text searches by relevance, with primaryKey as a tie breaker, unless the 'sort' query param is given
+// Other searches are sorted by the 'sort' query param. Either way, any 'cursor' query param is applied to where, see applyCursor
+func searchOrderBy(c fiber.Ctx, where *whereClause, sortableFields map[string]string, primaryKey string) (string, *fiber.Error) {
+	if len(where.ranks) > 0 && c.Query("sort") == "" {
+		return applyCursor(c, where, []sortKey{{field: "rank", column: where.rank(), desc: true}}, primaryKey)
+	}
+	return cursorOrderBy(c, where, sortableFields, primaryKey)
+}
+
+// suggestionSource is the table and column that "did you mean" suggestions for a search term are taken from
+type suggestionSource struct {
+	table  string
+	column string
+}
+
+// maxSuggestions is the maximum number of suggestions returned for each search term
+var maxSuggestions int = 5
+
+// searchSuggestions returns the closest matches by trigram similarity for each search term in the query that has a suggestionSource
+// Suggestions are only looked up when the search found no results, and are returned as GravityError meta, otherwise nil is returned
+// Failing to retrieve suggestions is logged rather than returned, as they are a nicety on top of the original error
+func searchSuggestions(db *pgx.Conn, c fiber.Ctx, searchErr *fiber.Error, suggestionSources map[string]suggestionSource) map[string]interface{} {
+	if searchErr.Code != fiber.ErrNotFound.Code {
+		return nil
+	}
+
+	suggestions := make(map[string][]string)
+	for searchTerm, source := range suggestionSources {
+		if c.Query(searchTerm) == "" {
+			continue
+		}
+
+		sql := fmt.Sprintf(`SELECT %[2]s FROM %[1]s WHERE %[2]s %% $1 GROUP BY %[2]s ORDER BY similarity(%[2]s, $1) DESC, %[2]s LIMIT $2`, source.table, source.column)
+		rows, err := db.Query(context.Background(), sql, c.Query(searchTerm), maxSuggestions)
+		if err != nil {
+			log.Printf("Unable to retrieve search suggestions: %v\n", err)
+			continue
+		}
+
+		for rows.Next() {
+			var suggestion string
+			if err := rows.Scan(&suggestion); err != nil {
+				log.Printf("Unable to retrieve search suggestions: %v\n", err)
+				break
+			}
+			suggestions[searchTerm] = append(suggestions[searchTerm], suggestion)
+		}
+		rows.Close()
+	}
+
+	if len(suggestions) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"suggestions": suggestions}
 }
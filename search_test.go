@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/objx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{value: "Agatha", expected: "Agatha"},
+		{value: "100%", expected: `100\%`},
+		{value: "a_b", expected: `a\_b`},
+		{value: `C:\books`, expected: `C:\\books`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, escapeLike(test.value))
+		})
+	}
+}
+
+func TestAddSearchTerm(t *testing.T) {
+	var tests = []struct {
+		match             string
+		expectedCondition string
+		expectedArg       string
+	}{
+		{match: matchExact, expectedCondition: "book.title=$1", expectedArg: "50%_off"},
+		{match: matchInsensitive, expectedCondition: "lower(book.title)=lower($1)", expectedArg: "50%_off"},
+		{match: matchPrefix, expectedCondition: `book.title ILIKE $1 ESCAPE '\'`, expectedArg: `50\%\_off%`},
+		{match: matchContains, expectedCondition: `book.title ILIKE $1 ESCAPE '\'`, expectedArg: `%50\%\_off%`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.match, func(t *testing.T) {
+			var where whereClause
+			where.addSearchTerm("book.title", SearchTerm{Term: "title", Value: "50%_off", Match: test.match})
+
+			assert.Equal(t, "WHERE "+test.expectedCondition, where.sql())
+			assert.Equal(t, []any{test.expectedArg}, where.args)
+		})
+	}
+}
+
+func TestAddSearchTermFulltext(t *testing.T) {
+	var where whereClause
+	where.addSearchTerm("book.title", SearchTerm{Term: "title", Value: "christmas poirot", Match: matchFulltext})
+
+	assert.Equal(t, "WHERE to_tsvector('english', book.title) @@ plainto_tsquery('english', $1)", where.sql())
+	assert.Equal(t, "(ts_rank(to_tsvector('english', book.title), plainto_tsquery('english', $1)))", where.rank())
+	assert.Equal(t, []any{"christmas poirot"}, where.args)
+}
+
+func TestAddRelatedSearchTerm(t *testing.T) {
+	var where whereClause
+	where.add("book.num_pages >= %s", 100)
+	where.addRelatedSearchTerm("book_author JOIN author ON author.author_id = book_author.author_id",
+		"book_author.book_id = book.book_id", "author.author_name", SearchTerm{Term: "author", Value: "christie agatha", Match: matchFulltext})
+
+	matches := "book_author.book_id = book.book_id AND to_tsvector('english', author.author_name) @@ plainto_tsquery('english', $2)"
+	assert.Equal(t, "WHERE book.num_pages >= $1 AND EXISTS (SELECT 1 FROM book_author JOIN author ON author.author_id = book_author.author_id WHERE "+matches+")", where.sql())
+	assert.Equal(t, "((SELECT MAX(ts_rank(to_tsvector('english', author.author_name), plainto_tsquery('english', $2))) FROM book_author JOIN author ON author.author_id = book_author.author_id WHERE "+matches+"))", where.rank())
+	assert.Equal(t, []any{100, "christie agatha"}, where.args)
+}
+
+func TestSearchSuggestionsOnlyForNotFound(t *testing.T) {
+	var db *pgx.Conn
+	var c fiber.Ctx
+
+	res := searchSuggestions(db, c, fiber.ErrBadRequest, authorSuggestionSources)
+
+	assert.Nil(t, res)
+}
+
+func TestSearchAllModels(t *testing.T) {
+	var tests = []struct {
+		search          string
+		expectedFirstId int
+	}{
+		{search: "/v1/publishers/search?name=Vintage", expectedFirstId: 2113},
+		{search: "/v1/publishers/search?name=vint&match=prefix", expectedFirstId: 2113},
+		{search: "/v1/countries/search?name=France", expectedFirstId: 70},
+		{search: "/v1/shipping-methods/search?name=priority&match=insensitive", expectedFirstId: 2},
+		{search: "/v1/orders/search?customerId=1", expectedFirstId: 1},
+		{search: "/v1/orders/search?customerId=1&shippingMethodId=3", expectedFirstId: 2001},
+		{search: "/v1/orders/search?customerId=1&status=Pending Delivery", expectedFirstId: 1},
+	}
+
+	r := initRouter()
+
+	for _, test := range tests {
+		t.Run(test.search, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", test.search, nil)
+			resp, err := r.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			a, _ := objx.FromJSON(string(body))
+
+			assert.Equal(t, test.expectedFirstId, a.Get("data[0].id").Int())
+		})
+	}
+}
+
+func TestUnifiedSearch(t *testing.T) {
+	r := initRouter()
+	req, _ := http.NewRequest("GET", "/v1/search?q=christie&types=author,book", nil)
+	resp, err := r.Test(req)
+	if err != nil {
+		t.Error(err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	a, _ := objx.FromJSON(string(body))
+
+	assert.Equal(t, 79, a.Get("data.author[0].id").Int())
+	assert.Equal(t, "Agatha Christie", a.Get("data.author[0].authorName").Str())
+	assert.True(t, a.Has("data.book"))
+	assert.False(t, a.Has("data.publisher"))
+}
+
+func TestUnifiedSearchCtx(t *testing.T) {
+	app := fiber.New()
+	app.Get("/v1/search", func(c fiber.Ctx) error {
+		c.Locals("limit", "10")
+		c.Locals("offset", "20")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			searchCtx := unifiedSearchCtx(c, c.Locals("limit"), c.Locals("offset"))
+			defer c.App().ReleaseCtx(searchCtx)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.Empty(t, searchCtx.Queries())
+				assert.Equal(t, "10", searchCtx.Locals("limit"))
+				assert.Equal(t, "20", searchCtx.Locals("offset"))
+				assert.False(t, paginated(searchCtx))
+				searchCtx.Locals("searched", true)
+			}()
+		}
+		wg.Wait()
+
+		assert.True(t, paginated(c))
+		assert.Nil(t, c.Locals("searched"))
+		return nil
+	})
+
+	req, _ := http.NewRequest("GET", "/v1/search?q=christie&types=book", nil)
+	if _, err := app.Test(req); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseUnifiedSearchTypes(t *testing.T) {
+	types, err := parseUnifiedSearchTypes("")
+	assert.Nil(t, err)
+	assert.Equal(t, validUnifiedSearchTypes, types)
+
+	types, err = parseUnifiedSearchTypes("author, book,author")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"author", "book"}, types)
+
+	_, err = parseUnifiedSearchTypes("book,foo")
+	assert.Equal(t, "invalid search type 'foo'. valid search types: [book author publisher customer]", err.Error())
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/jackc/pgx/v5"
@@ -13,11 +14,37 @@ type ShippingMethod struct {
 	Cost       float64 `json:"cost"`
 }
 
+// shippingMethodSortFields maps the sortable ShippingMethod fields to their columns
+var shippingMethodSortFields = map[string]string{
+	"id":         "shipping_method.method_id",
+	"methodName": "shipping_method.method_name",
+	"cost":       "shipping_method.cost",
+}
+
+// shippingMethodFilterFields maps the ShippingMethod fields that can be used in filter[field][operator] query params to their columns
+var shippingMethodFilterFields = map[string]filterField{
+	"id":         {column: "shipping_method.method_id", kind: filterInt},
+	"methodName": {column: "shipping_method.method_name", kind: filterText},
+	"cost":       {column: "shipping_method.cost", kind: filterNumeric},
+}
+
 // AllShippingMethods returns all shipping methods from the database as []ShippingMethod
+// Filtered by any filter[field][operator] query params, see parseFilters, and sorted by the 'sort' query param if given
 // []ShoppingMethod is returned in all cases, so requires a check for error being nil
 func AllShippingMethods(db *pgx.Conn, c fiber.Ctx) ([]ShippingMethod, error) {
 	var shippingMethods []ShippingMethod
-	rows, err := db.Query(context.Background(), "SELECT * FROM shipping_method LIMIT $1 OFFSET $2", c.Locals("limit"), c.Locals("offset"))
+	var where whereClause
+	if ferr := parseFilters(c, &where, shippingMethodFilterFields); ferr != nil {
+		return shippingMethods, ferr
+	}
+
+	orderBy, ferr := cursorOrderBy(c, &where, shippingMethodSortFields, "shipping_method.method_id")
+	if ferr != nil {
+		return shippingMethods, ferr
+	}
+
+	sql := "SELECT * FROM shipping_method " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
 	if err != nil {
 		return shippingMethods, err
 	}
@@ -37,3 +64,57 @@ func AllShippingMethods(db *pgx.Conn, c fiber.Ctx) ([]ShippingMethod, error) {
 	}
 	return shippingMethods, nil
 }
+
+// ShippingMethodById returns a single ShippingMethod from the database with the given id
+// pgx.ErrNoRows is returned if no shipping method exists with that id
+func ShippingMethodById(db *pgx.Conn, c fiber.Ctx, id int) (ShippingMethod, error) {
+	var s ShippingMethod
+	err := db.QueryRow(context.Background(), "SELECT * FROM shipping_method WHERE shipping_method.method_id=$1", id).Scan(&s.Id, &s.MethodName, &s.Cost)
+	return s, err
+}
+
+// ShippingMethodsBySearchTerm returns []ShippingMethod from the database where each searchTerm matches searchValue, combined with AND
+// Values are compared using the search term's match mode, see addSearchTerm
+// To avoid unparameterised user input, only defined search terms are handled, otherwise in 'invalid search term' error  is returned.
+// []ShippingMethod is returned in all cases, so requires a check for error being nil
+func ShippingMethodsBySearchTerm(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]ShippingMethod, error) {
+	var shippingMethods []ShippingMethod
+	var where whereClause
+	for _, st := range searchTerms {
+		switch st.Term {
+		case "name":
+			if st.Match == matchFulltext {
+				return shippingMethods, unsupportedMatchError(st)
+			}
+			where.addSearchTerm("shipping_method.method_name", st)
+		default:
+			return shippingMethods, errors.New("invalid search term")
+		}
+	}
+
+	if ferr := parseFilters(c, &where, shippingMethodFilterFields); ferr != nil {
+		return shippingMethods, ferr
+	}
+
+	orderBy, ferr := searchOrderBy(c, &where, shippingMethodSortFields, "shipping_method.method_id")
+	if ferr != nil {
+		return shippingMethods, ferr
+	}
+
+	sql := "SELECT * FROM shipping_method " + where.sql() + " " + orderBy
+	rows, err := queryPage(db, c, sql, where.args...)
+	if err != nil {
+		return shippingMethods, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s ShippingMethod
+		err := rows.Scan(&s.Id, &s.MethodName, &s.Cost)
+		if err != nil {
+			return shippingMethods, err
+		}
+		shippingMethods = append(shippingMethods, s)
+	}
+	return shippingMethods, nil
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -17,3 +20,34 @@ func TestAllShippingMethodsError(t *testing.T) {
 	assert.Equal(t, []ShippingMethod(nil), res)
 	assert.Equal(t, "conn closed", err.Error())
 }
+
+func TestShippingMethodByIdError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	_, err := ShippingMethodById(db, c, 1)
+
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestShippingMethodSearchError(t *testing.T) {
+	db := connectToDb()
+	c := initRouter().AcquireCtx()
+	db.Close(context.Background())
+
+	res, err := ShippingMethodsBySearchTerm(db, c, []SearchTerm{{Term: "name", Value: "Priority"}})
+
+	assert.Equal(t, []ShippingMethod(nil), res)
+	assert.Equal(t, "conn closed", err.Error())
+}
+
+func TestShippingMethodSearchInvalidTerm(t *testing.T) {
+	var db *pgx.Conn
+	var c fiber.Ctx
+
+	res, err := ShippingMethodsBySearchTerm(db, c, []SearchTerm{{Term: "foo", Value: "bar"}})
+
+	assert.Equal(t, []ShippingMethod(nil), res)
+	assert.Equal(t, errors.New("invalid search term"), err)
+}
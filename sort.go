@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// sortKey is a single field from the 'sort' query param and the SQL column or expression it sorts by
+type sortKey struct {
+	field  string
+	column string
+	desc   bool
+}
+
+// parseSort checks the comma separated 'sort' query param against sortableFields, which maps each API field name to its SQL column.
+// A field prefixed with '-' is sorted descending. defaultOrder is used when no sort is given, and is always appended
+// so that rows with equal sort values keep a stable order between pages - it should end with the model's primary key.
+// Returns the resulting ORDER BY clause, or a *fiber.Error with a 400 status for an unknown field
+func parseSort(c fiber.Ctx, sortableFields map[string]string, defaultOrder string) (string, *fiber.Error) {
+	keys, ferr := parseSortKeys(c, sortableFields)
+	if ferr != nil {
+		return "", ferr
+	}
+
+	var columns []string
+	for _, key := range keys {
+		columns = append(columns, key.sql())
+	}
+	columns = append(columns, defaultOrder)
+	return "ORDER BY " + strings.Join(columns, ", "), nil
+}
+
+// parseSortKeys returns the fields in the 'sort' query param as []sortKey, see parseSort
+func parseSortKeys(c fiber.Ctx, sortableFields map[string]string) ([]sortKey, *fiber.Error) {
+	var keys []sortKey
+
+	for _, field := range strings.Split(c.Query("sort"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+
+		column, ok := sortableFields[field]
+		if !ok {
+			var validFields []string
+			for k := range sortableFields {
+				validFields = append(validFields, k)
+			}
+			sort.Strings(validFields)
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid sort field '%v'. valid sort fields: %v", field, validFields))
+		}
+		keys = append(keys, sortKey{field: field, column: column, desc: desc})
+	}
+
+	return keys, nil
+}
+
+// sql returns the key as an ORDER BY column
+func (k sortKey) sql() string {
+	if k.desc {
+		return k.column + " DESC"
+	}
+	return k.column + " ASC"
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSort(t *testing.T) {
+	var tests = []struct {
+		sort     string
+		expected string
+	}{
+		{sort: "", expected: "ORDER BY book.book_id"},
+		{sort: "title", expected: "ORDER BY book.title ASC, book.book_id"},
+		{sort: "-publicationDate,title", expected: "ORDER BY book.publication_date DESC, book.title ASC, book.book_id"},
+		{sort: "title;DROP TABLE book", expected: "invalid sort field 'title;DROP TABLE book'. valid sort fields: [id isbn languageId numPages publicationDate publisherId title]"},
+		{sort: "-foo", expected: "invalid sort field 'foo'. valid sort fields: [id isbn languageId numPages publicationDate publisherId title]"},
+	}
+
+	app := fiber.New()
+	app.Get("/", func(c fiber.Ctx) error {
+		orderBy, err := parseSort(c, bookSortFields, "book.book_id")
+		if err != nil {
+			return c.SendString(err.Error())
+		}
+		return c.SendString(orderBy)
+	})
+
+	for _, test := range tests {
+		t.Run(test.sort, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/?"+url.Values{"sort": {test.sort}}.Encode(), nil)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Error(err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+			}
+
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/valyala/fasthttp"
+)
+
+// unifiedSearchType is a resource that can be searched by GET /v1/search, along with the search term the 'q' query param is used as
+type unifiedSearchType struct {
+	term   string
+	search func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) (interface{}, int, error)
+}
+
+// unifiedSearchTypes maps each value accepted by the 'types' query param to how that resource is searched
+var unifiedSearchTypes = map[string]unifiedSearchType{
+	"book":      {term: "title", search: unifiedSearchFunc(BooksBySearchTerm)},
+	"author":    {term: "name", search: unifiedSearchFunc(AuthorsBySearchTerm)},
+	"publisher": {term: "name", search: unifiedSearchFunc(PublishersBySearchTerm)},
+	"customer":  {term: "email", search: unifiedSearchFunc(CustomersBySearchTerm)},
+}
+
+// validUnifiedSearchTypes are searched in this order when the 'types' query param is not given
+var validUnifiedSearchTypes = []string{"book", "author", "publisher", "customer"}
+
+// unifiedSearchParams are the only query params accepted by the unified search
+var unifiedSearchParams = []string{"q", "types", "match", "limit", "offset", "format"}
+
+// unifiedSearchFunc wraps a model's search function so that it can be stored in unifiedSearchTypes
+// It also returns the number of results, and a nil result is returned as an empty []s so that every requested type is an array in the response
+func unifiedSearchFunc[s Searchable](searchFunc func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) ([]s, error)) func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) (interface{}, int, error) {
+	return func(db *pgx.Conn, c fiber.Ctx, searchTerms []SearchTerm) (interface{}, int, error) {
+		results, err := searchFunc(db, c, searchTerms)
+		if results == nil {
+			results = []s{}
+		}
+		return results, len(results), err
+	}
+}
+
+// HandleUnifiedSearch searches each of the resources in the 'types' query param for the 'q' query param, defaulting to all of validUnifiedSearchTypes.
+// The 'match' query param defaults to contains, as the query is usually partial input from a search box.
+// Each type is searched concurrently on a connection from pool.
+// It returns the results grouped by type, or a *fiber.Error with a 400 for an invalid query param and a 404 if no type has any results
+func HandleUnifiedSearch(pool *pgxpool.Pool, c fiber.Ctx) (map[string]interface{}, *fiber.Error) {
+	// Reading the query params here also means they are parsed before being read concurrently by the searches below
+	var queryParams []string
+	for k := range c.Queries() {
+		queryParams = append(queryParams, k)
+	}
+	sort.Strings(queryParams)
+	for _, k := range queryParams {
+		if !slices.Contains(unifiedSearchParams, k) {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid query param '%v'. valid query params: %v", k, unifiedSearchParams))
+		}
+	}
+
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return nil, fiber.NewError(fiber.ErrBadRequest.Code, "no search query found, 'q' is required")
+	}
+
+	match := c.Query("match", matchContains)
+	if !slices.Contains(validMatchModes, match) {
+		return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid match mode '%v'. valid match modes: %v", match, validMatchModes))
+	}
+
+	types, ferr := parseUnifiedSearchTypes(c.Query("types"))
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	// A *pgx.Conn can only run one query at a time, so each type is searched on its own pooled connection to run concurrently.
+	// A fiber.Ctx must not be used concurrently either, so each search also gets its own, see unifiedSearchCtx
+	searchCtxs := make([]fiber.Ctx, len(types))
+	for i := range types {
+		searchCtxs[i] = unifiedSearchCtx(c, c.Locals("limit"), c.Locals("offset"))
+		defer c.App().ReleaseCtx(searchCtxs[i])
+	}
+
+	results := make([]interface{}, len(types))
+	counts := make([]int, len(types))
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, t := range types {
+		wg.Add(1)
+		go func(i int, searchType unifiedSearchType, searchCtx fiber.Ctx) {
+			defer wg.Done()
+			conn, err := pool.Acquire(context.Background())
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer conn.Release()
+			results[i], counts[i], errs[i] = searchType.search(conn.Conn(), searchCtx, []SearchTerm{{Term: searchType.term, Value: q, Match: match}})
+		}(i, unifiedSearchTypes[t], searchCtxs[i])
+	}
+	wg.Wait()
+
+	grouped := make(map[string]interface{})
+	total := 0
+	for i, t := range types {
+		// Search functions return a *fiber.Error for problems with the request itself, such as an unsupported match mode
+		var ferr *fiber.Error
+		if errors.As(errs[i], &ferr) {
+			return nil, fiber.NewError(ferr.Code, fmt.Sprintf("%v: %v", t, ferr.Message))
+		}
+		if errs[i] != nil {
+			return nil, fiber.NewError(fiber.ErrInternalServerError.Code, fmt.Sprintf("error retrieving %v by search %v", t, errs[i].Error()))
+		}
+		grouped[t] = results[i]
+		total += counts[i]
+	}
+
+	if total == 0 {
+		return nil, fiber.NewError(fiber.ErrNotFound.Code, "no results found")
+	}
+	return grouped, nil
+}
+
+// parseUnifiedSearchTypes checks the comma separated 'types' query param against validUnifiedSearchTypes, ignoring duplicates
+// All of validUnifiedSearchTypes are returned if it is empty, otherwise a *fiber.Error with a 400 status is returned for an unknown type
+func parseUnifiedSearchTypes(typesParam string) ([]string, *fiber.Error) {
+	var types []string
+	for _, t := range strings.Split(typesParam, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || slices.Contains(types, t) {
+			continue
+		}
+		if !slices.Contains(validUnifiedSearchTypes, t) {
+			return nil, fiber.NewError(fiber.ErrBadRequest.Code, fmt.Sprintf("invalid search type '%v'. valid search types: %v", t, validUnifiedSearchTypes))
+		}
+		types = append(types, t)
+	}
+
+	if len(types) == 0 {
+		return validUnifiedSearchTypes, nil
+	}
+	return types, nil
+}
+
+// unifiedSearchCtx returns a new fiber.Ctx for one of the unified search's concurrent searches, with the request's limit and offset.
+// It has no query params, as everything the search needs is read from c beforehand and passed in its search terms.
+// Each type is paged separately, so there is no single pagination for the response, see paginated. It must be released with ReleaseCtx
+func unifiedSearchCtx(c fiber.Ctx, limit any, offset any) fiber.Ctx {
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI(c.Path())
+
+	searchCtx := c.App().AcquireCtx()
+	searchCtx.Reset(fctx)
+	searchCtx.Locals("limit", limit)
+	searchCtx.Locals("offset", offset)
+	searchCtx.Locals("paginate", false)
+	return searchCtx
+}